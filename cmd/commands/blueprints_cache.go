@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// blueprintsCache holds the blueprints discovered by get-blueprints so other commands can skip discovery
+type blueprintsCache struct {
+	GeneratedAt       time.Time      `json:"generatedAt"`
+	OldInstallationID string         `json:"oldInstallationId"`
+	Blueprints        map[string]int `json:"blueprints"` // blueprint -> entity count
+}
+
+// writeBlueprintsCache writes the discovered blueprints and their entity counts to a cache file
+func writeBlueprintsCache(path, oldInstallID string, counts map[string]int) error {
+	cache := blueprintsCache{
+		GeneratedAt:       time.Now().UTC(),
+		OldInstallationID: oldInstallID,
+		Blueprints:        counts,
+	}
+
+	data, _ := json.MarshalIndent(cache, "", "  ")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write blueprints cache: %w", err)
+	}
+	return nil
+}
+
+// readBlueprintsCache reads a cache file written by get-blueprints, warning when it is older than maxAge
+func readBlueprintsCache(path, oldInstallID string, maxAge time.Duration, log io.Writer) (map[string]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blueprints cache: %w", err)
+	}
+
+	var cache blueprintsCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("failed to parse blueprints cache: %w", err)
+	}
+
+	if cache.OldInstallationID != oldInstallID {
+		return nil, fmt.Errorf("blueprints cache was generated for installation %s, not %s", cache.OldInstallationID, oldInstallID)
+	}
+
+	if age := time.Since(cache.GeneratedAt); maxAge > 0 && age > maxAge {
+		fmt.Fprintf(log, "⚠️  Blueprints cache is %s old, counts may be stale. Re-run get-blueprints --cache-file to refresh it.\n", age.Round(time.Minute))
+	}
+
+	return cache.Blueprints, nil
+}
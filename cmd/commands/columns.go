@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseColumns splits a --columns value and checks every column is one of valid
+func parseColumns(value string, valid []string) ([]string, error) {
+	known := make(map[string]bool, len(valid))
+	for _, name := range valid {
+		known[name] = true
+	}
+
+	var columns []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("❌ unknown column %q, valid columns: %s", name, strings.Join(valid, ", "))
+		}
+		columns = append(columns, name)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("❌ --columns is empty, valid columns: %s", strings.Join(valid, ", "))
+	}
+	return columns, nil
+}
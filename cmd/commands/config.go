@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/joho/godotenv"
+	"github.com/omby8888/port-github-migrator/internal/redact"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envFileKeys holds the environment variables that were loaded from the .env file
+var envFileKeys = make(map[string]bool)
+
+// LoadEnvFile loads the .env file without overriding the environment, recording which keys it set
+func LoadEnvFile() {
+	values, err := godotenv.Read()
+	if err != nil {
+		return
+	}
+
+	for key := range values {
+		if _, exists := os.LookupEnv(key); !exists {
+			envFileKeys[key] = true
+		}
+	}
+
+	_ = godotenv.Load()
+}
+
+// globalSettings lists the global flags with the environment variable backing each of them
+var globalSettings = []struct {
+	flag   string
+	env    string
+	secret bool
+}{
+	{flag: "port-url", env: "PORT_API_URL"},
+	{flag: "client-id", env: "PORT_CLIENT_ID"},
+	{flag: "client-secret", env: "PORT_CLIENT_SECRET", secret: true},
+	{flag: "old-installation-id", env: "OLD_INSTALLATION_ID"},
+	{flag: "new-installation-id", env: "NEW_INSTALLATION_ID"},
+	{flag: "max-rps"},
+	{flag: "max-patch-body-bytes"},
+	{flag: "header", secret: true},
+	{flag: "user-agent"},
+	{flag: "new-datasource-kind"},
+	{flag: "new-datasource-suffix"},
+	{flag: "api-version"},
+	{flag: "search-path"},
+	{flag: "json-compact"},
+	{flag: "verbose"},
+}
+
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "config",
+		Short:        "Show the effective configuration and where each value comes from",
+		Long:         "Print the resolved global configuration (with secrets redacted) and the source of each value: flag, env, .env file or default.",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+
+			for _, setting := range globalSettings {
+				flag := cmd.Flags().Lookup(setting.flag)
+				if flag == nil {
+					continue
+				}
+
+				value := flag.Value.String()
+				if setting.secret {
+					value = redact.Secret(value)
+				}
+
+				fmt.Fprintf(w, "%s\t%s\t%s\n", setting.flag, value, settingSource(cmd, setting.flag, setting.env))
+			}
+
+			return w.Flush()
+		},
+	}
+
+	return cmd
+}
+
+// settingSource reports where the value of a global flag was resolved from
+func settingSource(cmd *cobra.Command, flag, env string) string {
+	if cmd.Flags().Changed(flag) {
+		return "flag"
+	}
+	if env != "" {
+		if _, exists := os.LookupEnv(env); exists {
+			if envFileKeys[env] {
+				return fmt.Sprintf("%s (.env file)", env)
+			}
+			return fmt.Sprintf("%s (env)", env)
+		}
+	}
+	return "default"
+}
+
+// secretFlags are the flags redacted from a printed command invocation
+var secretFlags = map[string]bool{
+	"client-secret":        true,
+	"target-client-secret": true,
+	"header":               true,
+	"notify-webhook":       true, // webhook URLs often carry a token
+	"event-webhook":        true,
+}
+
+// redactedInvocation rebuilds the command line from the arguments and the flags that were set, with secrets redacted
+func redactedInvocation(cmd *cobra.Command, args []string) string {
+	parts := append([]string{cmd.CommandPath()}, args...)
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		value := flag.Value.String()
+		if secretFlags[flag.Name] {
+			value = redact.Secret(value)
+		}
+		if flag.Value.Type() == "bool" && value == "true" {
+			parts = append(parts, "--"+flag.Name)
+			return
+		}
+		parts = append(parts, fmt.Sprintf("--%s=%s", flag.Name, value))
+	})
+	return strings.Join(parts, " ")
+}
+
+// secretValues returns the values of the command's secret flags, from a flag or the environment, to be
+// masked wherever they show up in the output. Headers are left to the bearer token masking, most
+// of them aren't secret.
+func secretValues(cmd *cobra.Command) []string {
+	var values []string
+	for name := range secretFlags {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || name == "header" || flag.Value.String() == "" {
+			continue
+		}
+		values = append(values, flag.Value.String())
+	}
+	return values
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/omby8888/port-github-migrator/internal/jsonout"
+	"github.com/omby8888/port-github-migrator/internal/port"
+	"github.com/omby8888/port-github-migrator/internal/textwidth"
+)
+
+// blueprintCoverage is which blueprints the old and new installations ingest into
+type blueprintCoverage struct {
+	Both    []string `json:"both"`
+	OnlyOld []string `json:"onlyOld"` // the new installation doesn't ingest into them, a coverage gap
+	OnlyNew []string `json:"onlyNew"`
+}
+
+func NewCoverageCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "coverage",
+		Short:        "Compare the blueprints the old and new installations ingest into",
+		Long:         "List the blueprints of the old installation's datasources next to the new installation's, highlighting the blueprints only one of them ingests into. Run it before migrating to find coverage gaps: entities of a blueprint the new installation doesn't ingest into would be orphaned.",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			portURL, _ := cmd.Flags().GetString("port-url")
+			clientID, _ := cmd.Flags().GetString("client-id")
+			clientSecret, _ := cmd.Flags().GetString("client-secret")
+			oldInstallID, _ := cmd.Flags().GetString("old-installation-id")
+			newInstallID, _ := cmd.Flags().GetString("new-installation-id")
+			output, _ := cmd.Flags().GetString("output")
+
+			// Validate required parameters
+			var missing []string
+			if clientID == "" {
+				missing = append(missing, "--client-id")
+			}
+			if clientSecret == "" {
+				missing = append(missing, "--client-secret")
+			}
+			if oldInstallID == "" {
+				missing = append(missing, "--old-installation-id")
+			}
+			if newInstallID == "" {
+				missing = append(missing, "--new-installation-id")
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("❌ missing required options: %v", missing)
+			}
+			if output != "table" && output != "json" {
+				return fmt.Errorf("❌ invalid --output %q, expected table or json", output)
+			}
+
+			client := port.NewClient(portURL, clientID, clientSecret, clientOptions(cmd)...)
+
+			blueprints, err := client.GetBlueprintsByInstallations(oldInstallID, newInstallID)
+			if err != nil {
+				return fmt.Errorf("failed to get blueprints: %w", err)
+			}
+			coverage := compareCoverage(blueprints[oldInstallID], blueprints[newInstallID])
+
+			if output == "json" {
+				encoder := jsonout.NewEncoder(cmd.OutOrStdout(), jsonCompact(cmd))
+				return encoder.Encode(coverage)
+			}
+
+			all := append(append(append([]string{}, coverage.Both...), coverage.OnlyOld...), coverage.OnlyNew...)
+			sort.Strings(all)
+			onlyOld := make(map[string]bool, len(coverage.OnlyOld))
+			for _, bp := range coverage.OnlyOld {
+				onlyOld[bp] = true
+			}
+			onlyNew := make(map[string]bool, len(coverage.OnlyNew))
+			for _, bp := range coverage.OnlyNew {
+				onlyNew[bp] = true
+			}
+
+			out := cmd.OutOrStdout()
+			width := textwidth.NameColumn(all)
+			printNameHeader(out, width, "OLD  NEW")
+			for _, bp := range all {
+				switch {
+				case onlyOld[bp]:
+					fmt.Fprintf(out, "%s ✅   ❌\n", textwidth.Pad(bp, width))
+				case onlyNew[bp]:
+					fmt.Fprintf(out, "%s ❌   ✅\n", textwidth.Pad(bp, width))
+				default:
+					fmt.Fprintf(out, "%s ✅   ✅\n", textwidth.Pad(bp, width))
+				}
+			}
+
+			fmt.Fprintln(out)
+			fmt.Fprintf(out, "✅ %d blueprints ingested by both installations\n", len(coverage.Both))
+			if len(coverage.OnlyOld) > 0 {
+				fmt.Fprintf(out, "⚠️  %d blueprints only ingested by the old installation, their entities would be orphaned by a migration: %v\n", len(coverage.OnlyOld), coverage.OnlyOld)
+			}
+			if len(coverage.OnlyNew) > 0 {
+				fmt.Fprintf(out, "🆕 %d blueprints only ingested by the new installation: %v\n", len(coverage.OnlyNew), coverage.OnlyNew)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("output", "table", "Output format: table or json")
+
+	return cmd
+}
+
+// compareCoverage sorts the old and new installations' blueprints into both, only old and only new
+func compareCoverage(oldBlueprints, newBlueprints []string) blueprintCoverage {
+	coverage := blueprintCoverage{Both: []string{}, OnlyOld: []string{}, OnlyNew: []string{}}
+
+	ingestedByNew := make(map[string]bool, len(newBlueprints))
+	for _, bp := range newBlueprints {
+		ingestedByNew[bp] = true
+	}
+	ingestedByOld := make(map[string]bool, len(oldBlueprints))
+	for _, bp := range oldBlueprints {
+		ingestedByOld[bp] = true
+		if ingestedByNew[bp] {
+			coverage.Both = append(coverage.Both, bp)
+		} else {
+			coverage.OnlyOld = append(coverage.OnlyOld, bp)
+		}
+	}
+	for _, bp := range newBlueprints {
+		if !ingestedByOld[bp] {
+			coverage.OnlyNew = append(coverage.OnlyNew, bp)
+		}
+	}
+
+	sort.Strings(coverage.Both)
+	sort.Strings(coverage.OnlyOld)
+	sort.Strings(coverage.OnlyNew)
+	return coverage
+}
@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/omby8888/port-github-migrator/internal/jsonout"
+	"github.com/omby8888/port-github-migrator/internal/port"
+	"github.com/omby8888/port-github-migrator/internal/textwidth"
+)
+
+// datasourceSnapshot holds the number of entities on the old and new datasource per blueprint at a point in time
+type datasourceSnapshot struct {
+	TakenAt           time.Time                   `json:"takenAt"`
+	OldInstallationID string                      `json:"oldInstallationId"`
+	NewInstallationID string                      `json:"newInstallationId"`
+	Blueprints        map[string]datasourceTotals `json:"blueprints"`
+}
+
+// datasourceTotals is the number of a blueprint's entities on each datasource
+type datasourceTotals struct {
+	Old int `json:"old"`
+	New int `json:"new"`
+}
+
+// snapshotComparison is a blueprint's totals in two snapshots
+type snapshotComparison struct {
+	Blueprint string           `json:"blueprint"`
+	Before    datasourceTotals `json:"before"`
+	After     datasourceTotals `json:"after"`
+}
+
+func NewDatasourceSnapshotCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "datasource-snapshot [blueprint...]",
+		Short: "Snapshot how many entities are on the old and new datasource, and compare snapshots",
+		Long: `Count the entities on the old and on the new datasource of each blueprint, all blueprints of the old installation by default.
+
+Save a snapshot before and after the migration with --save, then compare them with --before and --after to prove the migration moved everything.
+With only --before, the saved snapshot is compared to a fresh one.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			portURL, _ := cmd.Flags().GetString("port-url")
+			clientID, _ := cmd.Flags().GetString("client-id")
+			clientSecret, _ := cmd.Flags().GetString("client-secret")
+			oldInstallID, _ := cmd.Flags().GetString("old-installation-id")
+			newInstallID, _ := cmd.Flags().GetString("new-installation-id")
+			savePath, _ := cmd.Flags().GetString("save")
+			beforePath, _ := cmd.Flags().GetString("before")
+			afterPath, _ := cmd.Flags().GetString("after")
+			output, _ := cmd.Flags().GetString("output")
+
+			if output != "table" && output != "json" {
+				return fmt.Errorf("❌ invalid --output %q, expected table or json", output)
+			}
+			if afterPath != "" && beforePath == "" {
+				return fmt.Errorf("❌ --after can only be used with --before")
+			}
+			if afterPath != "" && (len(args) > 0 || savePath != "") {
+				return fmt.Errorf("❌ comparing two saved snapshots cannot be combined with blueprint arguments or --save")
+			}
+
+			// Two saved snapshots are compared without calling Port
+			if afterPath != "" {
+				before, err := readDatasourceSnapshot(beforePath)
+				if err != nil {
+					return err
+				}
+				after, err := readDatasourceSnapshot(afterPath)
+				if err != nil {
+					return err
+				}
+				return writeSnapshotComparison(cmd, before, after, output)
+			}
+
+			// Validate required parameters
+			var missing []string
+			if clientID == "" {
+				missing = append(missing, "--client-id")
+			}
+			if clientSecret == "" {
+				missing = append(missing, "--client-secret")
+			}
+			if oldInstallID == "" {
+				missing = append(missing, "--old-installation-id")
+			}
+			if newInstallID == "" {
+				missing = append(missing, "--new-installation-id")
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("❌ missing required options: %v", missing)
+			}
+
+			var before *datasourceSnapshot
+			if beforePath != "" {
+				saved, err := readDatasourceSnapshot(beforePath)
+				if err != nil {
+					return err
+				}
+				before = saved
+			}
+
+			// Create Port client
+			client := port.NewClient(portURL, clientID, clientSecret, clientOptions(cmd)...)
+
+			blueprints := args
+			if len(blueprints) == 0 && before != nil {
+				// Compare the same blueprints as the saved snapshot
+				for bp := range before.Blueprints {
+					blueprints = append(blueprints, bp)
+				}
+			}
+			if len(blueprints) == 0 {
+				discovered, err := client.GetBlueprintsByDataSource(oldInstallID)
+				if err != nil {
+					return fmt.Errorf("failed to get blueprints: %w", err)
+				}
+				blueprints = discovered
+			}
+			sort.Strings(blueprints)
+
+			snapshot, err := takeDatasourceSnapshot(client, blueprints, oldInstallID, newInstallID, cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+
+			if savePath != "" {
+				data, _ := json.MarshalIndent(snapshot, "", "  ")
+				if err := os.WriteFile(savePath, data, 0o644); err != nil {
+					return fmt.Errorf("failed to write snapshot: %w", err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "📝 Saved the snapshot of %d blueprints to %s\n", len(snapshot.Blueprints), savePath)
+			}
+
+			if before != nil {
+				return writeSnapshotComparison(cmd, before, snapshot, output)
+			}
+
+			if output == "json" {
+				encoder := jsonout.NewEncoder(cmd.OutOrStdout(), jsonCompact(cmd))
+				return encoder.Encode(snapshot)
+			}
+
+			width := textwidth.NameColumn(blueprints)
+			printNameHeader(cmd.OutOrStdout(), width, "OLD      NEW")
+			for _, bp := range blueprints {
+				totals := snapshot.Blueprints[bp]
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %-8d %d\n", textwidth.Pad(bp, width), totals.Old, totals.New)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("save", "", "Write the snapshot to this file")
+	cmd.Flags().String("before", "", "Compare a snapshot saved before the migration to --after, or to a fresh snapshot")
+	cmd.Flags().String("after", "", "Snapshot saved after the migration to compare --before to")
+	cmd.Flags().String("output", "table", "Output format: table or json")
+
+	return cmd
+}
+
+// takeDatasourceSnapshot counts each blueprint's entities on the old and the new datasource
+func takeDatasourceSnapshot(client *port.Client, blueprints []string, oldInstallID, newInstallID string, log io.Writer) (*datasourceSnapshot, error) {
+	snapshot := &datasourceSnapshot{
+		TakenAt:           time.Now().UTC(),
+		OldInstallationID: oldInstallID,
+		NewInstallationID: newInstallID,
+		Blueprints:        make(map[string]datasourceTotals),
+	}
+
+	for _, bp := range blueprints {
+		oldEntities, err := client.SearchOldEntitiesByBlueprint(bp, oldInstallID)
+		if port.IsNotFound(err) {
+			fmt.Fprintf(log, "⚠️  Blueprint %s no longer exists, skipping\n", bp)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to search old entities of blueprint %s: %w", bp, err)
+		}
+		newEntities, err := client.SearchNewEntitiesByBlueprint(bp, newInstallID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search new entities of blueprint %s: %w", bp, err)
+		}
+
+		snapshot.Blueprints[bp] = datasourceTotals{Old: len(oldEntities), New: len(newEntities)}
+	}
+
+	return snapshot, nil
+}
+
+// readDatasourceSnapshot reads a snapshot saved with --save
+func readDatasourceSnapshot(path string) (*datasourceSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot datasourceSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	return &snapshot, nil
+}
+
+// writeSnapshotComparison prints each blueprint's totals before and after, and whether anything is left on the old datasource
+func writeSnapshotComparison(cmd *cobra.Command, before, after *datasourceSnapshot, output string) error {
+	if before.OldInstallationID != after.OldInstallationID || before.NewInstallationID != after.NewInstallationID {
+		fmt.Fprintln(cmd.ErrOrStderr(), "⚠️  The snapshots were taken for different installations")
+	}
+
+	blueprintSet := make(map[string]bool)
+	for bp := range before.Blueprints {
+		blueprintSet[bp] = true
+	}
+	for bp := range after.Blueprints {
+		blueprintSet[bp] = true
+	}
+
+	comparisons := make([]snapshotComparison, 0, len(blueprintSet))
+	for bp := range blueprintSet {
+		comparisons = append(comparisons, snapshotComparison{
+			Blueprint: bp,
+			Before:    before.Blueprints[bp],
+			After:     after.Blueprints[bp],
+		})
+	}
+	sort.Slice(comparisons, func(i, j int) bool {
+		return comparisons[i].Blueprint < comparisons[j].Blueprint
+	})
+
+	if output == "json" {
+		encoder := jsonout.NewEncoder(cmd.OutOrStdout(), jsonCompact(cmd))
+		return encoder.Encode(comparisons)
+	}
+
+	remaining := 0
+	for _, c := range comparisons {
+		fmt.Fprintf(cmd.OutOrStdout(), "%-33s before: %d old / %d new; after: %d old / %d new\n",
+			c.Blueprint, c.Before.Old, c.Before.New, c.After.Old, c.After.New)
+		remaining += c.After.Old
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout())
+	if remaining > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "⚠️  %d entities are still on the old datasource\n", remaining)
+	} else {
+		fmt.Fprintln(cmd.OutOrStdout(), "✅ No entities are left on the old datasource")
+	}
+	return nil
+}
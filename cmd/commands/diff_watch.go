@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+// watchDiff re-runs the comparison every interval and redraws the summary counts of each blueprint
+// with their change since the previous poll, until ctx is cancelled by Ctrl+C. The comparison's
+// clients must use ctx so an interrupt also cancels the round in flight. A failed round is reported
+// and the watch keeps polling.
+func watchDiff(ctx context.Context, cmd *cobra.Command, interval time.Duration, compare func() ([]*models.DiffResult, []error)) error {
+	out := cmd.OutOrStdout()
+	var previous map[string]models.DiffSummary
+	for {
+		results, errs := compare()
+		if ctx.Err() != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "\n👋 Stopped watching")
+			return nil
+		}
+		var failed error
+		for _, err := range errs {
+			if err != nil {
+				failed = err
+				break
+			}
+		}
+		if failed != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  %s: failed to compare blueprints, retrying in %s: %v\n", time.Now().Format("15:04:05"), interval, failed)
+			if !waitForNextPoll(ctx, cmd, interval) {
+				return nil
+			}
+			continue
+		}
+
+		// Clear the screen and redraw from the top
+		fmt.Fprint(out, "\033[H\033[2J")
+		fmt.Fprintf(out, "👀 %s, refreshing every %s (Ctrl+C to stop)\n\n", time.Now().Format("15:04:05"), interval)
+
+		current := make(map[string]models.DiffSummary, len(results))
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BLUEPRINT\tIDENTICAL\tNOT MIGRATED\tCHANGED\tORPHANED")
+		for _, result := range results {
+			key := result.SourceBlueprint + " → " + result.TargetBlueprint
+			summary := result.Summary
+			current[key] = summary
+
+			before, seen := previous[key]
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key,
+				watchCount(summary.Identical, before.Identical, seen),
+				watchCount(summary.NotMigrated, before.NotMigrated, seen),
+				watchCount(summary.Changed, before.Changed, seen),
+				watchCount(summary.Orphaned, before.Orphaned, seen))
+		}
+		w.Flush()
+		previous = current
+
+		if !waitForNextPoll(ctx, cmd, interval) {
+			return nil
+		}
+	}
+}
+
+// waitForNextPoll waits for the interval, returning false when the watch was stopped instead
+func waitForNextPoll(ctx context.Context, cmd *cobra.Command, interval time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		fmt.Fprintln(cmd.ErrOrStderr(), "\n👋 Stopped watching")
+		return false
+	case <-time.After(interval):
+		return true
+	}
+}
+
+// watchCount formats a count with its change since the previous poll, when there is one
+func watchCount(count, before int, seen bool) string {
+	if !seen || count == before {
+		return fmt.Sprintf("%d", count)
+	}
+	return fmt.Sprintf("%d (%+d)", count, count-before)
+}
+
+// isTerminal reports whether w is a terminal, looking through wrapping writers such as the redaction
+func isTerminal(w io.Writer) bool {
+	for {
+		wrapper, ok := w.(interface{ Unwrap() io.Writer })
+		if !ok {
+			break
+		}
+		w = wrapper.Unwrap()
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/spf13/cobra"
+)
+
+func TestWatchDiff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+
+	result := &models.DiffResult{SourceBlueprint: "githubRepository", TargetBlueprint: "githubRepository"}
+	polls := 0
+	compare := func() ([]*models.DiffResult, []error) {
+		polls++
+		switch polls {
+		case 1:
+			// A failed poll is reported and the watch goes on
+			return []*models.DiffResult{nil}, []error{errors.New("port is down")}
+		case 2:
+			result.Summary.Identical = 3
+			return []*models.DiffResult{result}, []error{nil}
+		default:
+			// Ctrl+C while comparing cancels the round in flight
+			cancel()
+			<-ctx.Done()
+			return []*models.DiffResult{nil}, []error{ctx.Err()}
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- watchDiff(ctx, cmd, 10*time.Millisecond, compare) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("watchDiff() = %v, want a clean stop", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchDiff() didn't stop when cancelled")
+	}
+
+	if polls != 3 {
+		t.Errorf("polled %d times, want 3", polls)
+	}
+	if !strings.Contains(stderr.String(), "failed to compare blueprints, retrying in 10ms: port is down") {
+		t.Errorf("the failed poll wasn't reported:\n%s", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Stopped watching") || strings.Contains(stderr.String(), "context canceled") {
+		t.Errorf("the interrupt wasn't a clean stop:\n%s", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "githubRepository → githubRepository  3") {
+		t.Errorf("the successful poll wasn't drawn:\n%s", stdout.String())
+	}
+}
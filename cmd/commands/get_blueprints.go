@@ -2,10 +2,16 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/omby8888/port-github-migrator/internal/jsonout"
 	"github.com/omby8888/port-github-migrator/internal/port"
+	"github.com/omby8888/port-github-migrator/internal/textwidth"
 )
 
 func NewGetBlueprintsCommand() *cobra.Command {
@@ -20,6 +26,37 @@ func NewGetBlueprintsCommand() *cobra.Command {
 			clientSecret, _ := cmd.Flags().GetString("client-secret")
 			oldInstallID, _ := cmd.Flags().GetString("old-installation-id")
 			includeEmpty, _ := cmd.Flags().GetBool("include-empty")
+			cacheFile, _ := cmd.Flags().GetString("cache-file")
+			columnsStr, _ := cmd.Flags().GetString("columns")
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			sample, _ := cmd.Flags().GetInt("sample")
+			output, _ := cmd.Flags().GetString("output")
+
+			if output != "table" && output != "wide" && output != "json" {
+				return fmt.Errorf("❌ invalid --output %q, expected table, wide or json", output)
+			}
+			if sample < 0 {
+				return fmt.Errorf("❌ --sample must not be negative")
+			}
+			if columnsStr != "" && (output != "table" || sample > 0) {
+				return fmt.Errorf("❌ --columns cannot be used with --output wide or json, or --sample")
+			}
+			if output == "wide" && sample > 0 {
+				return fmt.Errorf("❌ --sample cannot be used with --output wide")
+			}
+
+			// The wide table sizes the columns to the longest blueprint instead of the fixed 33 characters
+			var columns []string
+			if output == "wide" {
+				columns = []string{"name", "entities", "datasource"}
+			}
+			if columnsStr != "" {
+				parsed, err := parseColumns(columnsStr, blueprintColumnNames())
+				if err != nil {
+					return err
+				}
+				columns = parsed
+			}
 
 			// Validate required parameters
 			var missing []string
@@ -37,7 +74,7 @@ func NewGetBlueprintsCommand() *cobra.Command {
 			}
 
 			// Create Port client
-			client := port.NewClient(portURL, clientID, clientSecret)
+			client := port.NewClient(portURL, clientID, clientSecret, clientOptions(cmd)...)
 
 			// Get blueprints
 			blueprints, err := client.GetBlueprintsByDataSource(oldInstallID)
@@ -48,24 +85,74 @@ func NewGetBlueprintsCommand() *cobra.Command {
 			// Sort and display with entity counts
 			sort.Strings(blueprints)
 
-			fmt.Println("NAME                              ENTITIES")
-			fmt.Println("──────────────────────────────────────────")
+			var table *blueprintTable
+			if output != "json" {
+				table = newBlueprintTable(cmd.OutOrStdout(), columns, oldInstallID, textwidth.NameColumn(blueprints))
+			}
+			listings := []blueprintListing{}
+			counts := make(map[string]int)
 			for _, bp := range blueprints {
 				// Count entities for this blueprint
-				entities, err := client.SearchOldEntitiesByBlueprint(bp, oldInstallID)
+				start := time.Now()
+				count, err := client.CountEntitiesByDatasource(bp, port.OldDatasource(oldInstallID))
+				if verbose {
+					fmt.Fprintf(cmd.ErrOrStderr(), "⏱️  %s counted in %s\n", bp, time.Since(start).Round(time.Millisecond))
+				}
 				if err != nil {
 					// If we can't get count, just show the blueprint name
-					fmt.Printf("%-33s ?\n", bp)
+					if table != nil {
+						table.row(bp, -1)
+					} else {
+						listings = append(listings, blueprintListing{Blueprint: bp})
+					}
+					if cacheFile != "" {
+						fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  %s couldn't be counted and is left out of the cache\n", bp)
+					}
 					continue
 				}
-				count := len(entities)
+				counts[bp] = count
 				
 				// Skip empty blueprints unless --include-empty is set
 				if count == 0 && !includeEmpty {
 					continue
 				}
 				
-				fmt.Printf("%-33s %d\n", bp, count)
+				// A first page of entities to check the search matches sensible ones
+				var samples []entitySample
+				if sample > 0 && count > 0 {
+					entities, err := client.SampleOldEntities(bp, oldInstallID, sample)
+					if err != nil {
+						fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  Failed to sample entities of %s: %v\n", bp, err)
+					}
+					for _, e := range entities {
+						samples = append(samples, entitySample{Identifier: e.Identifier, Title: e.Title})
+					}
+				}
+
+				if table == nil {
+					count := count
+					listings = append(listings, blueprintListing{Blueprint: bp, Entities: &count, Samples: samples})
+					continue
+				}
+				table.row(bp, count)
+				for _, s := range samples {
+					fmt.Fprintf(cmd.OutOrStdout(), "       • %s\t%s\n", s.Identifier, s.Title)
+				}
+			}
+			if table != nil {
+				table.flush()
+			} else {
+				encoder := jsonout.NewEncoder(cmd.OutOrStdout(), jsonCompact(cmd))
+				if err := encoder.Encode(listings); err != nil {
+					return err
+				}
+			}
+
+			if cacheFile != "" {
+				if err := writeBlueprintsCache(cacheFile, oldInstallID, counts); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "📝 Cached %d blueprints to %s\n", len(counts), cacheFile)
 			}
 
 			return nil
@@ -73,6 +160,104 @@ func NewGetBlueprintsCommand() *cobra.Command {
 	}
 
 	cmd.Flags().Bool("include-empty", false, "Include blueprints with 0 entities")
+	cmd.Flags().String("columns", "", "Columns of the table, any of "+strings.Join(blueprintColumnNames(), ", ")+" (default: name,entities)")
+	cmd.Flags().Int("sample", 0, "Print up to N sample entity identifiers and titles of each blueprint from the old datasource (0 = none)")
+	cmd.Flags().String("output", "table", "Output format: table, wide (full blueprint names and the old datasource) or json, json nests the samples per blueprint")
+	cmd.Flags().String("cache-file", "", "Write the discovered blueprints and counts to a file for migrate --blueprints-cache")
 
 	return cmd
 }
+
+// blueprintListing is the JSON representation of a blueprint, Entities is null when it couldn't be counted
+type blueprintListing struct {
+	Blueprint string         `json:"blueprint"`
+	Entities  *int           `json:"entities"`
+	Samples   []entitySample `json:"samples,omitempty"`
+}
+
+// entitySample is an entity shown by --sample
+type entitySample struct {
+	Identifier string `json:"identifier"`
+	Title      string `json:"title,omitempty"`
+}
+
+// blueprintColumns maps the columns of the blueprints table to their values, count is -1 when unknown
+var blueprintColumns = map[string]func(blueprint string, count int, oldInstallID string) string{
+	"name": func(blueprint string, count int, oldInstallID string) string {
+		return blueprint
+	},
+	"entities": func(blueprint string, count int, oldInstallID string) string {
+		if count < 0 {
+			return "?"
+		}
+		return fmt.Sprintf("%d", count)
+	},
+	"datasource": func(blueprint string, count int, oldInstallID string) string {
+		return port.OldDatasource(oldInstallID)
+	},
+}
+
+// blueprintColumnNames returns the sorted names of the blueprints table columns
+func blueprintColumnNames() []string {
+	names := make([]string, 0, len(blueprintColumns))
+	for name := range blueprintColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printNameHeader prints the header of a fixed layout table with a name column of width
+func printNameHeader(out io.Writer, width int, rest string) {
+	header := textwidth.Pad("NAME", width) + " " + rest
+	fmt.Fprintln(out, header)
+	fmt.Fprintln(out, strings.Repeat("─", textwidth.Width(header)))
+}
+
+// blueprintTable prints the blueprints table, in the fixed name and entities layout unless columns are chosen
+type blueprintTable struct {
+	out          io.Writer
+	w            *tabwriter.Writer
+	columns      []string
+	oldInstallID string
+	nameWidth    int // width of the fixed layout's name column
+}
+
+func newBlueprintTable(out io.Writer, columns []string, oldInstallID string, nameWidth int) *blueprintTable {
+	t := &blueprintTable{out: out, columns: columns, oldInstallID: oldInstallID, nameWidth: nameWidth}
+	if columns == nil {
+		printNameHeader(out, nameWidth, "ENTITIES")
+		return t
+	}
+
+	t.w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(t.w, strings.Join(headers, "\t"))
+	return t
+}
+
+func (t *blueprintTable) row(blueprint string, count int) {
+	if t.w == nil {
+		if count < 0 {
+			fmt.Fprintf(t.out, "%s ?\n", textwidth.Pad(blueprint, t.nameWidth))
+			return
+		}
+		fmt.Fprintf(t.out, "%s %d\n", textwidth.Pad(blueprint, t.nameWidth), count)
+		return
+	}
+
+	values := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		values[i] = blueprintColumns[column](blueprint, count, t.oldInstallID)
+	}
+	fmt.Fprintln(t.w, strings.Join(values, "\t"))
+}
+
+func (t *blueprintTable) flush() {
+	if t.w != nil {
+		t.w.Flush()
+	}
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/omby8888/port-github-migrator/internal/textwidth"
+)
+
+func TestBlueprintTableAlignment(t *testing.T) {
+	var out bytes.Buffer
+	blueprints := []string{
+		"githubRepository",
+		"githubRepositoryDeploymentEnvironmentProtectionRule",
+		"服务目录",
+		"🚀deployments",
+		"café",
+	}
+	table := newBlueprintTable(&out, nil, "12345", textwidth.NameColumn(blueprints))
+	for i, bp := range blueprints {
+		table.row(bp, i*100)
+	}
+	table.row("unknownCount", -1)
+	table.flush()
+
+	want := `NAME                                                ENTITIES
+────────────────────────────────────────────────────────────
+githubRepository                                    0
+githubRepositoryDeploymentEnvironmentProtectionRule 100
+服务目录                                            200
+🚀deployments                                       300
+café                                                400
+unknownCount                                        ?
+`
+	if out.String() != want {
+		t.Errorf("table:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/omby8888/port-github-migrator/internal/jsonout"
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+// datasourceCount is the number of entities of a blueprint carrying a datasource
+type datasourceCount struct {
+	Datasource string `json:"datasource"`
+	Entities   int    `json:"entities"`
+}
+
+// blueprintDatasources holds the datasource distribution of a blueprint
+type blueprintDatasources struct {
+	Blueprint   string            `json:"blueprint"`
+	Datasources []datasourceCount `json:"datasources"`
+}
+
+func NewGetDatasourcesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "get-datasources <blueprint> [blueprint...]",
+		Short:        "Show the datasources of a blueprint's entities",
+		Long:         "List the distinct datasources among all entities of each blueprint with the number of entities carrying each of them.",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			portURL, _ := cmd.Flags().GetString("port-url")
+			clientID, _ := cmd.Flags().GetString("client-id")
+			clientSecret, _ := cmd.Flags().GetString("client-secret")
+			output, _ := cmd.Flags().GetString("output")
+
+			// Validate required parameters
+			var missing []string
+			if clientID == "" {
+				missing = append(missing, "--client-id")
+			}
+			if clientSecret == "" {
+				missing = append(missing, "--client-secret")
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("❌ missing required options: %v", missing)
+			}
+			if output != "table" && output != "json" {
+				return fmt.Errorf("❌ invalid --output %q, expected table or json", output)
+			}
+
+			// Create Port client
+			client := port.NewClient(portURL, clientID, clientSecret, clientOptions(cmd)...)
+
+			var results []blueprintDatasources
+			for _, bp := range args {
+				entities, err := client.SearchAllEntitiesByBlueprint(bp)
+				if err != nil {
+					return fmt.Errorf("failed to search entities for blueprint %s: %w", bp, err)
+				}
+				results = append(results, blueprintDatasources{
+					Blueprint:   bp,
+					Datasources: countDatasources(entities),
+				})
+			}
+
+			if output == "json" {
+				encoder := jsonout.NewEncoder(cmd.OutOrStdout(), jsonCompact(cmd))
+				return encoder.Encode(results)
+			}
+
+			for i, result := range results {
+				if i > 0 {
+					fmt.Fprintln(cmd.OutOrStdout())
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "📦 %s\n", result.Blueprint)
+				fmt.Fprintln(cmd.OutOrStdout(), "ENTITIES  DATASOURCE")
+				for _, ds := range result.Datasources {
+					fmt.Fprintf(cmd.OutOrStdout(), "%8d  %s\n", ds.Entities, ds.Datasource)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("output", "table", "Output format: table or json")
+
+	return cmd
+}
+
+// countDatasources groups entities by datasource, most common first
+func countDatasources(entities []port.Entity) []datasourceCount {
+	counts := make(map[string]int)
+	for _, e := range entities {
+		datasource := e.Datasource
+		if datasource == "" {
+			datasource = "(none)"
+		}
+		counts[datasource]++
+	}
+
+	result := make([]datasourceCount, 0, len(counts))
+	for ds, count := range counts {
+		result = append(result, datasourceCount{Datasource: ds, Entities: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Entities != result[j].Entities {
+			return result[i].Entities > result[j].Entities
+		}
+		return result[i].Datasource < result[j].Datasource
+	})
+
+	return result
+}
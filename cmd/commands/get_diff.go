@@ -1,10 +1,18 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/omby8888/port-github-migrator/internal/diff"
+	"github.com/omby8888/port-github-migrator/internal/models"
 	"github.com/omby8888/port-github-migrator/internal/port"
 )
 
@@ -14,8 +22,22 @@ func NewGetDiffCommand() *cobra.Command {
 		Short:        "Compare entities between source and target blueprints",
 		Long:         `Compare entities from the source blueprint (with old datasource) to the target blueprint (with new datasource).`,
 		Args: func(cmd *cobra.Command, args []string) error {
+			all, _ := cmd.Flags().GetBool("all")
+			if all {
+				if len(args) > 0 {
+					return fmt.Errorf("❌ cannot use both blueprint arguments and --all flag")
+				}
+				return nil
+			}
+			sourceFile, _ := cmd.Flags().GetString("source-file")
+			if sourceFile != "" {
+				if len(args) != 1 {
+					return fmt.Errorf("❌ --source-file takes just the targetBlueprint argument. Usage: get-diff --source-file <backup.json> <targetBlueprint>")
+				}
+				return nil
+			}
 			if len(args) < 2 {
-				return fmt.Errorf("❌ both sourceBlueprint and targetBlueprint arguments are required. Usage: get-diff <sourceBlueprint> <targetBlueprint>")
+				return fmt.Errorf("❌ both sourceBlueprint and targetBlueprint arguments are required. Usage: get-diff <sourceBlueprint> <targetBlueprint> or get-diff --all")
 			}
 			return nil
 		},
@@ -28,9 +50,40 @@ func NewGetDiffCommand() *cobra.Command {
 			newInstallID, _ := cmd.Flags().GetString("new-installation-id")
 			showDiffs, _ := cmd.Flags().GetBool("show-diffs")
 			limitStr, _ := cmd.Flags().GetString("limit")
-
-			sourceBlueprint := args[0]
-			targetBlueprint := args[1]
+			offset, _ := cmd.Flags().GetInt("offset")
+			all, _ := cmd.Flags().GetBool("all")
+			blueprintMapStr, _ := cmd.Flags().GetString("blueprint-map")
+			blueprintMapFile, _ := cmd.Flags().GetString("blueprint-map-file")
+			ignoreProperties, _ := cmd.Flags().GetStringArray("ignore-property")
+			significantProps, _ := cmd.Flags().GetStringArray("significant-property")
+			nullEqualsMissing, _ := cmd.Flags().GetBool("null-equals-missing")
+			diagnose, _ := cmd.Flags().GetBool("diagnose")
+			onlyChangedCount, _ := cmd.Flags().GetBool("only-changed-count")
+			entitiesFile, _ := cmd.Flags().GetString("entities-file")
+			strictRelations, _ := cmd.Flags().GetBool("strict-relations")
+			relationsMode, _ := cmd.Flags().GetString("relations-compare-mode")
+			parallel, _ := cmd.Flags().GetInt("parallel-blueprint-diff")
+			output, _ := cmd.Flags().GetString("output")
+			changedOnly, _ := cmd.Flags().GetBool("changed-only")
+			changeKinds, _ := cmd.Flags().GetStringArray("change-kind")
+			includeMeta, _ := cmd.Flags().GetBool("include-meta")
+			columnsStr, _ := cmd.Flags().GetString("columns")
+			strict, _ := cmd.Flags().GetBool("strict")
+			ignoreCase, _ := cmd.Flags().GetBool("ignore-case")
+			idTransforms, _ := cmd.Flags().GetStringArray("id-transform")
+			groupBy, _ := cmd.Flags().GetString("group-by")
+			explainGap, _ := cmd.Flags().GetBool("explain-gap")
+			compact, _ := cmd.Flags().GetBool("compact")
+			watch, _ := cmd.Flags().GetBool("watch")
+			watchInterval, _ := cmd.Flags().GetDuration("interval")
+			onlyWithDiffs, _ := cmd.Flags().GetBool("only-with-diffs")
+			relation, _ := cmd.Flags().GetString("relation")
+			datasourceOnly, _ := cmd.Flags().GetBool("compare-datasource-only")
+			targetPortURL, _ := cmd.Flags().GetString("target-port-url")
+			targetClientID, _ := cmd.Flags().GetString("target-client-id")
+			targetClientSecret, _ := cmd.Flags().GetString("target-client-secret")
+			sourceFile, _ := cmd.Flags().GetString("source-file")
+			showTitles, _ := cmd.Flags().GetBool("show-titles")
 
 			// Validate required parameters
 			var missing []string
@@ -40,40 +93,366 @@ func NewGetDiffCommand() *cobra.Command {
 			if clientSecret == "" {
 				missing = append(missing, "--client-secret")
 			}
-			if oldInstallID == "" {
+			// A backup replaces the old search, the old installation only matters to --strict then
+			if oldInstallID == "" && (sourceFile == "" || strict) {
 				missing = append(missing, "--old-installation-id")
 			}
 			if newInstallID == "" {
 				missing = append(missing, "--new-installation-id")
 			}
+			// Target credentials compare against another Port organization
+			crossOrg := targetPortURL != "" || targetClientID != "" || targetClientSecret != ""
+			if crossOrg {
+				if targetClientID == "" {
+					missing = append(missing, "--target-client-id")
+				}
+				if targetClientSecret == "" {
+					missing = append(missing, "--target-client-secret")
+				}
+			}
 			if len(missing) > 0 {
 				return fmt.Errorf("❌ missing required options: %v", missing)
 			}
 
+			if !all && (blueprintMapStr != "" || blueprintMapFile != "") {
+				return fmt.Errorf("❌ --blueprint-map and --blueprint-map-file can only be used with --all")
+			}
+			if output != "table" && output != "json" {
+				return fmt.Errorf("❌ invalid --output %q, expected table or json", output)
+			}
+			if changedOnly && output != "json" {
+				return fmt.Errorf("❌ --changed-only can only be used with --output json")
+			}
+			if onlyChangedCount && output == "json" {
+				return fmt.Errorf("❌ --only-changed-count cannot be used with --output json")
+			}
+			var columns []string
+			if columnsStr != "" {
+				if output != "table" || onlyChangedCount {
+					return fmt.Errorf("❌ --columns can only be used with the table output")
+				}
+				parsed, err := parseColumns(columnsStr, diff.ChangeColumns())
+				if err != nil {
+					return err
+				}
+				columns = parsed
+			}
+			if groupBy != "" {
+				if groupBy != "status" {
+					return fmt.Errorf("❌ invalid --group-by %q, expected status", groupBy)
+				}
+				if !all {
+					return fmt.Errorf("❌ --group-by can only be used with --all")
+				}
+				if output != "table" || onlyChangedCount || columns != nil || diagnose {
+					return fmt.Errorf("❌ --group-by cannot be used with --output json, --only-changed-count, --columns or --diagnose")
+				}
+			}
+			if explainGap && (onlyChangedCount || columns != nil || groupBy != "" || changedOnly || diagnose) {
+				return fmt.Errorf("❌ --explain-gap cannot be used with --only-changed-count, --columns, --group-by, --changed-only or --diagnose")
+			}
+			if watch {
+				if output != "table" || explainGap || groupBy != "" || onlyChangedCount || columns != nil || diagnose {
+					return fmt.Errorf("❌ --watch cannot be used with --output json, --explain-gap, --group-by, --only-changed-count, --columns or --diagnose")
+				}
+				if watchInterval <= 0 {
+					return fmt.Errorf("❌ --interval must be positive")
+				}
+				if !isTerminal(cmd.OutOrStdout()) {
+					return fmt.Errorf("❌ --watch redraws the summary and needs stdout to be a terminal")
+				}
+			}
+			if relation != "" {
+				if explainGap || onlyChangedCount || columns != nil || groupBy != "" || changedOnly || diagnose || watch || len(changeKinds) > 0 {
+					return fmt.Errorf("❌ --relation cannot be used with --explain-gap, --only-changed-count, --columns, --group-by, --changed-only, --diagnose, --watch or --change-kind")
+				}
+				if relationsMode == diff.RelationsKeysOnly {
+					return fmt.Errorf("❌ --relation needs the relation targets, it cannot be used with --relations-compare-mode keys-only")
+				}
+			}
+			if datasourceOnly {
+				if relation != "" || explainGap || onlyChangedCount || columns != nil || groupBy != "" || changedOnly || diagnose || watch || onlyWithDiffs || entitiesFile != "" {
+					return fmt.Errorf("❌ --compare-datasource-only cannot be used with --relation, --explain-gap, --only-changed-count, --columns, --group-by, --changed-only, --diagnose, --watch, --only-with-diffs or --entities-file")
+				}
+			}
+			if onlyWithDiffs {
+				if !all {
+					return fmt.Errorf("❌ --only-with-diffs can only be used with --all")
+				}
+				if watch || groupBy != "" {
+					return fmt.Errorf("❌ --only-with-diffs cannot be used with --watch or --group-by")
+				}
+			}
+			if offset < 0 {
+				return fmt.Errorf("❌ --offset must not be negative")
+			}
+			if all && entitiesFile != "" {
+				return fmt.Errorf("❌ --entities-file cannot be used with --all")
+			}
+			if sourceFile != "" && (all || datasourceOnly || diagnose) {
+				return fmt.Errorf("❌ --source-file cannot be used with --all, --compare-datasource-only or --diagnose")
+			}
+
+			var identifiers []string
+			if entitiesFile != "" {
+				lines, err := readLines(entitiesFile)
+				if err != nil {
+					return err
+				}
+				if len(lines) == 0 {
+					return fmt.Errorf("❌ no identifiers found in %s", entitiesFile)
+				}
+				identifiers = lines
+			}
+
 			// Parse limit
 			limit := 10
 			if limitStr != "" {
 				fmt.Sscanf(limitStr, "%d", &limit)
 			}
+			if limit < 1 {
+				return fmt.Errorf("❌ --limit must be at least 1")
+			}
+
+			if strictRelations {
+				if cmd.Flags().Changed("relations-compare-mode") && relationsMode != diff.RelationsExact {
+					return fmt.Errorf("❌ --strict-relations conflicts with --relations-compare-mode %s", relationsMode)
+				}
+				relationsMode = diff.RelationsExact
+			}
+
+			// --watch runs until Ctrl+C, which also cancels the comparison in flight
+			ctx := cmd.Context()
+			if watch {
+				var stop context.CancelFunc
+				ctx, stop = signal.NotifyContext(ctx, os.Interrupt)
+				defer stop()
+			}
 
 			// Create Port client
-			client := port.NewClient(portURL, clientID, clientSecret)
+			client := port.NewClient(portURL, clientID, clientSecret, append(clientOptions(cmd), port.WithContext(ctx))...)
+
+			var targetClient *port.Client
+			if crossOrg {
+				if targetPortURL == "" {
+					targetPortURL = portURL
+				}
+				targetClient = port.NewClient(targetPortURL, targetClientID, targetClientSecret, append(clientOptions(cmd), port.WithContext(ctx))...)
+			}
 
 			// Create diff service
-			diffService := diff.NewService(client)
+			diffService, err := diff.NewService(client, targetClient, models.DiffOptions{
+				IgnoreProperties:     ignoreProperties,
+				SignificantProps:     significantProps,
+				NullEqualsMissing:    nullEqualsMissing,
+				Identifiers:          identifiers,
+				RelationsCompareMode: relationsMode,
+				ChangeKinds:          changeKinds,
+				IncludeMeta:          includeMeta,
+				Strict:               strict,
+				IgnoreCase:           ignoreCase,
+				IDTransforms:         idTransforms,
+				JSONCompact:          jsonCompact(cmd),
+				ShowTitles:           showTitles,
+			})
+			if err != nil {
+				return fmt.Errorf("❌ %w", err)
+			}
+			diffService.SetOutput(cmd.OutOrStdout())
 
-			// Run comparison
-			result, err := diffService.CompareBlueprints(sourceBlueprint, targetBlueprint, oldInstallID, newInstallID)
+			events, closeEvents, err := eventSink(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to compare blueprints: %w", err)
+				return err
 			}
+			defer closeEvents()
+			diffService.SetEventSink(events)
+
+			// Build the list of source → target pairs to compare
+			type blueprintPair struct {
+				source string
+				target string
+			}
+			var pairs []blueprintPair
+			var backup []port.Entity
+
+			if sourceFile != "" {
+				entities, err := diff.ReadBackup(sourceFile)
+				if err != nil {
+					return fmt.Errorf("❌ %w", err)
+				}
+				if len(entities) == 0 {
+					return fmt.Errorf("❌ no entities found in %s", sourceFile)
+				}
+				backup = entities
+				pairs = append(pairs, blueprintPair{source: diff.BackupBlueprint(backup, args[0]), target: args[0]})
+			} else if all {
+				blueprintMap, err := loadBlueprintMap(blueprintMapStr, blueprintMapFile)
+				if err != nil {
+					return err
+				}
 
-			// Print summary
-			diffService.PrintSummary(result)
+				blueprints, err := client.GetBlueprintsByDataSource(oldInstallID)
+				if err != nil {
+					return fmt.Errorf("failed to get blueprints: %w", err)
+				}
+				sort.Strings(blueprints)
 
-			// Show detailed diffs if enabled
-			if showDiffs && len(result.Changes) > 0 {
-				diffService.PrintDetailedDiffs(result.Changes, limit)
+				// Every mapped blueprint must be one the old installation actually ingested into
+				discovered := make(map[string]bool)
+				for _, bp := range blueprints {
+					discovered[bp] = true
+				}
+				var unknown []string
+				for source := range blueprintMap {
+					if !discovered[source] {
+						unknown = append(unknown, source)
+					}
+				}
+				if len(unknown) > 0 {
+					sort.Strings(unknown)
+					return fmt.Errorf("❌ --blueprint-map references blueprints not managed by the old installation: %v", unknown)
+				}
+
+				for _, bp := range blueprints {
+					target := bp
+					if mapped, ok := blueprintMap[bp]; ok {
+						target = mapped
+					}
+					pairs = append(pairs, blueprintPair{source: bp, target: target})
+				}
+			} else {
+				pairs = append(pairs, blueprintPair{source: args[0], target: args[1]})
+			}
+
+			// Compare with a bounded pool of workers, results are kept in blueprint order
+			if parallel < 1 {
+				parallel = 1
+			}
+			compareAll := func() ([]*models.DiffResult, []error) {
+				results := make([]*models.DiffResult, len(pairs))
+				errs := make([]error, len(pairs))
+				forEachParallel(len(pairs), parallel, func(i int) {
+					if backup != nil {
+						results[i], errs[i] = diffService.CompareBackup(backup, pairs[i].source, pairs[i].target, oldInstallID, newInstallID)
+						return
+					}
+					results[i], errs[i] = diffService.CompareBlueprints(pairs[i].source, pairs[i].target, oldInstallID, newInstallID)
+				})
+				return results, errs
+			}
+
+			// Just which identifiers are on which datasource, no property comparison
+			if datasourceOnly {
+				exports := make([]diff.DatasourceExport, len(pairs))
+				errs := make([]error, len(pairs))
+				forEachParallel(len(pairs), parallel, func(i int) {
+					exports[i], errs[i] = diffService.CompareDatasources(pairs[i].source, pairs[i].target, oldInstallID, newInstallID)
+				})
+				for _, err := range errs {
+					if err != nil {
+						return fmt.Errorf("failed to compare blueprints: %w", err)
+					}
+				}
+				if output == "json" {
+					return diffService.WriteDatasourceJSON(exports)
+				}
+				for _, export := range exports {
+					diffService.PrintDatasourceComparison(export)
+				}
+				return nil
+			}
+
+			if watch {
+				return watchDiff(ctx, cmd, watchInterval, compareAll)
+			}
+
+			results, errs := compareAll()
+
+			var exports []diff.ResultExport
+			var gaps []diff.GapExport
+			var relationExports []diff.RelationExport
+			for i := range pairs {
+				result, err := results[i], errs[i]
+				if err != nil {
+					return fmt.Errorf("failed to compare blueprints: %w", err)
+				}
+
+				// Identical blueprints still count towards the totals printed at the end
+				if onlyWithDiffs && !diff.HasDifferences(result) {
+					continue
+				}
+
+				// Just the entities whose named relation differs
+				if relation != "" {
+					export := diffService.RelationDiffs(result, relation)
+					if output == "json" {
+						relationExports = append(relationExports, export)
+					} else {
+						diffService.PrintRelationDiffs(export)
+					}
+					continue
+				}
+
+				// Just the entities behind the difference in counts
+				if explainGap {
+					gap := diff.ExplainGap(result)
+					if output == "json" {
+						gaps = append(gaps, gap)
+					} else {
+						diffService.PrintGap(gap)
+					}
+					continue
+				}
+
+				if output == "json" {
+					exports = append(exports, diffService.Export(result, changedOnly))
+					continue
+				}
+
+				// Grouped once every blueprint is compared
+				if groupBy != "" {
+					continue
+				}
+
+				// Fast convergence check, just the number of changed entities
+				if onlyChangedCount {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s → %s: %d changed\n", result.SourceBlueprint, result.TargetBlueprint, result.Summary.Changed)
+					continue
+				}
+
+				// Print summary
+				diffService.PrintSummary(result)
+
+				// A table of the chosen columns replaces the detailed diffs
+				if columns != nil {
+					diffService.PrintChangesTable(result.Changes, columns)
+				} else if showDiffs && len(result.Changes) > 0 {
+					diffService.PrintDetailedDiffs(result.Changes, offset, limit, compact)
+				}
+
+				if diagnose {
+					diagnosis, err := diffService.DiagnoseDatasources(result)
+					if err != nil {
+						return fmt.Errorf("failed to diagnose datasources: %w", err)
+					}
+					diffService.PrintDiagnosis(diagnosis)
+				}
+			}
+
+			if output == "json" && relation != "" {
+				return diffService.WriteRelationJSON(relationExports)
+			}
+			if output == "json" && explainGap {
+				return diffService.WriteGapJSON(gaps)
+			}
+			if output == "json" {
+				return diffService.WriteJSON(exports)
+			}
+			if groupBy != "" {
+				diffService.PrintGroupedSummary(results)
+			}
+			if onlyWithDiffs {
+				diffService.PrintCleanCount(results)
 			}
 
 			return nil
@@ -82,6 +461,98 @@ func NewGetDiffCommand() *cobra.Command {
 
 	cmd.Flags().Bool("show-diffs", true, "Show detailed property differences")
 	cmd.Flags().String("limit", "10", "Limit number of shown changes")
+	cmd.Flags().Bool("compact", false, "Print each changed entity on one line with just the changed paths, not their values")
+	cmd.Flags().Int("offset", 0, "Skip this many changed entities, sorted by identifier, to page through them with --limit")
+	cmd.Flags().Bool("all", false, "Compare all blueprints managed by the old installation")
+	cmd.Flags().String("blueprint-map", "", "Map source to target blueprints for --all (e.g. old1=new1,old2=new2)")
+	cmd.Flags().String("blueprint-map-file", "", "File with one old=new blueprint mapping per line for --all")
+	cmd.Flags().StringArray("ignore-property", nil, "Ignore a flattened property path in the diff, supports wildcards (e.g. 'properties.sync_*'). Repeatable")
+	cmd.Flags().StringArray("significant-property", nil, "Only count an entity as changed when this flattened property path, or a path under it, differs; other differences are listed but don't count. Supports wildcards, repeatable")
+	cmd.Flags().StringArray("change-kind", nil, "Only count differences of this kind: property, relation, title or meta. Repeatable (default: all)")
+	cmd.Flags().Bool("include-meta", false, "Also compare createdAt, updatedAt, createdBy and updatedBy")
+	cmd.Flags().Bool("null-equals-missing", false, "Treat properties set to null as equal to missing properties")
+	cmd.Flags().Bool("strict-relations", false, "Compare relations exactly, same as --relations-compare-mode exact")
+	cmd.Flags().String("relations-compare-mode", diff.RelationsNormalized, "How relations are compared: normalized (ignore array order and single-element arrays), exact, ignore-order or keys-only")
+	cmd.Flags().Int("parallel-blueprint-diff", 1, "Number of blueprints compared concurrently with --all")
+	cmd.Flags().String("output", "table", "Output format: table or json")
+	cmd.Flags().Bool("changed-only", false, "With --output json, only export changed entities")
+	cmd.Flags().Bool("diagnose", false, "Report the datasources actually present on the target blueprint")
+	cmd.Flags().Bool("only-changed-count", false, "Only print the number of changed entities, skipping property diffs")
+	cmd.Flags().String("columns", "", "Print the changed, not migrated and orphaned entities as a table of these columns instead of detailed diffs (e.g. identifier,type,changedProps)")
+	cmd.Flags().Bool("ignore-case", false, "Match old and new entity identifiers case-insensitively, reporting the matches that needed it")
+	cmd.Flags().String("audit-log", "", "Append a JSON line per compared blueprint with its summary counts to this file")
+	cmd.Flags().String("event-webhook", "", "POST a JSON event per compared blueprint with its summary counts to this URL")
+	cmd.Flags().StringArray("id-transform", nil, "Normalize old and new identifiers before matching them, applied in order: "+strings.Join(diff.IdentifierTransforms(), ", ")+". Repeatable")
+	cmd.Flags().Bool("strict", false, "Only compare old entities whose datasource is exactly the old installation's, reporting the rest")
+	cmd.Flags().Bool("watch", false, "Re-run the comparison every --interval and redraw the summary counts with their change since the previous poll, until Ctrl+C")
+	cmd.Flags().Duration("interval", 30*time.Second, "Time between comparisons with --watch")
+	cmd.Flags().Bool("explain-gap", false, "Explain a difference between the old and new counts: list the identifiers and titles only in old (not migrated) and only in new (orphaned)")
+	cmd.Flags().Bool("compare-datasource-only", false, "Fast convergence check: only fetch identifiers and datasources and list the migrated, still old and only new identifiers, without comparing properties")
+	cmd.Flags().String("relation", "", "Only report the entities whose relation of this name differs or is missing on the new side, with its old and new value")
+	cmd.Flags().Bool("only-with-diffs", false, "With --all, only print the blueprints with not migrated, changed or orphaned entities, followed by the totals of all blueprints")
+	cmd.Flags().String("group-by", "", "With --all, group the blueprints by status (fully migrated, mostly not migrated, orphaned or changed) instead of a summary per blueprint")
+	cmd.Flags().String("target-port-url", "", "Port API URL of the target organization (default: --port-url)")
+	cmd.Flags().String("target-client-id", "", "Client ID of the target organization, searches the new entities there")
+	cmd.Flags().String("target-client-secret", "", "Client secret of the target organization")
+	cmd.Flags().String("entities-file", "", "File with one entity identifier per line to restrict the comparison to")
+	cmd.Flags().Bool("show-titles", false, "List the not migrated, orphaned and changed entities with their titles, not just their identifiers (also in --output json)")
+	cmd.Flags().String("source-file", "", "Compare a JSON backup of the old entities instead of searching them, e.g. after they were migrated. Takes just the targetBlueprint argument")
 
 	return cmd
 }
+
+// forEachParallel calls fn with each index below n, running up to parallel calls at once
+func forEachParallel(n, parallel int, fn func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// loadBlueprintMap merges the inline --blueprint-map value and the --blueprint-map-file contents
+func loadBlueprintMap(inline, file string) (map[string]string, error) {
+	blueprintMap := make(map[string]string)
+
+	var entries []string
+	if inline != "" {
+		entries = append(entries, strings.Split(inline, ",")...)
+	}
+
+	if file != "" {
+		lines, err := readLines(file)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, lines...)
+	}
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		source := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || source == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("❌ invalid blueprint mapping %q, expected old=new", entry)
+		}
+		if _, exists := blueprintMap[source]; exists {
+			return nil, fmt.Errorf("❌ blueprint %q is mapped more than once", source)
+		}
+		blueprintMap[source] = strings.TrimSpace(parts[1])
+	}
+
+	return blueprintMap, nil
+}
@@ -0,0 +1,24 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGetDiffRejectsLimitBelowOne(t *testing.T) {
+	for _, limit := range []string{"0", "-1"} {
+		t.Run(limit, func(t *testing.T) {
+			root := NewRootCommand()
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+			root.SetArgs([]string{"get-diff", "repo", "repo", "--client-id", "id", "--client-secret", "secret",
+				"--old-installation-id", "1", "--new-installation-id", "2", "--limit", limit})
+
+			err := root.Execute()
+			if err == nil || !strings.Contains(err.Error(), "--limit must be at least 1") {
+				t.Errorf("Execute() = %v, want the --limit error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/omby8888/port-github-migrator/internal/diff"
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+func NewGetEntityDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "get-entity-diff <blueprint> <identifier>",
+		Short:        "Compare a single entity between the old and new datasources",
+		Long:         `Fetch one entity with the old datasource and with the new datasource and print every property, relation and title difference.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			portURL, _ := cmd.Flags().GetString("port-url")
+			clientID, _ := cmd.Flags().GetString("client-id")
+			clientSecret, _ := cmd.Flags().GetString("client-secret")
+			oldInstallID, _ := cmd.Flags().GetString("old-installation-id")
+			newInstallID, _ := cmd.Flags().GetString("new-installation-id")
+			targetBlueprint, _ := cmd.Flags().GetString("target-blueprint")
+			ignoreProperties, _ := cmd.Flags().GetStringArray("ignore-property")
+			nullEqualsMissing, _ := cmd.Flags().GetBool("null-equals-missing")
+			strictRelations, _ := cmd.Flags().GetBool("strict-relations")
+			relationsMode, _ := cmd.Flags().GetString("relations-compare-mode")
+			includeMeta, _ := cmd.Flags().GetBool("include-meta")
+
+			// Validate required parameters
+			var missing []string
+			if clientID == "" {
+				missing = append(missing, "--client-id")
+			}
+			if clientSecret == "" {
+				missing = append(missing, "--client-secret")
+			}
+			if oldInstallID == "" {
+				missing = append(missing, "--old-installation-id")
+			}
+			if newInstallID == "" {
+				missing = append(missing, "--new-installation-id")
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("❌ missing required options: %v", missing)
+			}
+
+			blueprint, identifier := args[0], args[1]
+			if targetBlueprint == "" {
+				targetBlueprint = blueprint
+			}
+
+			if strictRelations {
+				if cmd.Flags().Changed("relations-compare-mode") && relationsMode != diff.RelationsExact {
+					return fmt.Errorf("❌ --strict-relations conflicts with --relations-compare-mode %s", relationsMode)
+				}
+				relationsMode = diff.RelationsExact
+			}
+
+			// Create Port client
+			client := port.NewClient(portURL, clientID, clientSecret, clientOptions(cmd)...)
+
+			diffService, err := diff.NewService(client, nil, models.DiffOptions{
+				IgnoreProperties:     ignoreProperties,
+				NullEqualsMissing:    nullEqualsMissing,
+				RelationsCompareMode: relationsMode,
+				IncludeMeta:          includeMeta,
+			})
+			if err != nil {
+				return fmt.Errorf("❌ %w", err)
+			}
+			diffService.SetOutput(cmd.OutOrStdout())
+
+			result, err := diffService.CompareEntity(blueprint, targetBlueprint, identifier, oldInstallID, newInstallID)
+			if err != nil {
+				return fmt.Errorf("failed to compare entity: %w", err)
+			}
+
+			diffService.PrintEntityDiff(result, identifier)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("target-blueprint", "", "Blueprint of the new entity (default: same as the old one)")
+	cmd.Flags().StringArray("ignore-property", nil, "Ignore a flattened property path in the diff, supports wildcards (e.g. 'properties.sync_*'). Repeatable")
+	cmd.Flags().Bool("null-equals-missing", false, "Treat properties set to null as equal to missing properties")
+	cmd.Flags().Bool("strict-relations", false, "Compare relations exactly, same as --relations-compare-mode exact")
+	cmd.Flags().String("relations-compare-mode", diff.RelationsNormalized, "How relations are compared: normalized (ignore array order and single-element arrays), exact, ignore-order or keys-only")
+	cmd.Flags().Bool("include-meta", false, "Also compare createdAt, updatedAt, createdBy and updatedBy")
+
+	return cmd
+}
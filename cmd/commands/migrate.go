@@ -1,21 +1,29 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/omby8888/port-github-migrator/internal/migrator"
 	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/notify"
 	"github.com/omby8888/port-github-migrator/internal/port"
+	"github.com/omby8888/port-github-migrator/internal/report"
+	"github.com/omby8888/port-github-migrator/internal/textwidth"
 )
 
 func NewMigrateCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:          "migrate [blueprint]",
-		Short:        "Migrate Ownership of entities from a specific blueprint or all blueprints",
+		Use:          "migrate [blueprint...]",
+		Short:        "Migrate Ownership of entities from specific blueprints or all blueprints",
 		Long:         `Migrate Ownership of entities from the old GitHub App integration to the new GitHub Ocean integration.`,
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			startedAt := time.Now()
 			portURL, _ := cmd.Flags().GetString("port-url")
 			clientID, _ := cmd.Flags().GetString("client-id")
 			clientSecret, _ := cmd.Flags().GetString("client-secret")
@@ -23,18 +31,117 @@ func NewMigrateCommand() *cobra.Command {
 			newInstallID, _ := cmd.Flags().GetString("new-installation-id")
 			dryRun, _ := cmd.Flags().GetBool("dry-run")
 			all, _ := cmd.Flags().GetBool("all")
+			planFile, _ := cmd.Flags().GetString("plan-file")
+			fromPlan, _ := cmd.Flags().GetString("from-plan")
+			notifyWebhook, _ := cmd.Flags().GetString("notify-webhook")
+			verify, _ := cmd.Flags().GetBool("verify")
+			resumeFrom, _ := cmd.Flags().GetString("resume-from-blueprint")
+			previewLimit, _ := cmd.Flags().GetInt("preview-limit")
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			integrationVersion, _ := cmd.Flags().GetString("integration-version")
+			blueprintsCachePath, _ := cmd.Flags().GetString("blueprints-cache")
+			cacheMaxAge, _ := cmd.Flags().GetDuration("cache-max-age")
+			output, _ := cmd.Flags().GetString("output")
+			blueprintRetries, _ := cmd.Flags().GetInt("blueprint-retries")
+			blueprintRetryDelay, _ := cmd.Flags().GetDuration("blueprint-retry-delay")
+			strict, _ := cmd.Flags().GetBool("strict")
+			preserveTitle, _ := cmd.Flags().GetBool("preserve-title")
+			migrateRelations, _ := cmd.Flags().GetBool("migrate-relations")
+			maxFailures, _ := cmd.Flags().GetInt("max-failures")
+			summaryOnly, _ := cmd.Flags().GetBool("summary-only")
+			noPromptBelow, _ := cmd.Flags().GetInt("no-prompt-below")
+			manifestFile, _ := cmd.Flags().GetString("manifest-file")
+			allowUningested, _ := cmd.Flags().GetBool("allow-uningested")
+			errorFile, _ := cmd.Flags().GetString("error-file")
+			fromErrorFile, _ := cmd.Flags().GetString("from-error-file")
+			reportFile, _ := cmd.Flags().GetString("report-file")
+			countConcurrency, _ := cmd.Flags().GetInt("count-concurrency")
+			noWarning, _ := cmd.Flags().GetBool("no-warning")
+			maxConsecutiveFailures, _ := cmd.Flags().GetInt("max-consecutive-failures")
+			deadline, _ := cmd.Flags().GetDuration("deadline")
+			verifyIntegrity, _ := cmd.Flags().GetBool("verify-integrity")
+			checkOrphans, _ := cmd.Flags().GetBool("check-orphans")
+			allowOrphans, _ := cmd.Flags().GetBool("allow-orphans")
+			confirmTimeout, _ := cmd.Flags().GetDuration("confirm-timeout")
+			batchDelay, _ := cmd.Flags().GetDuration("batch-delay")
+			emptySearchRetries, _ := cmd.Flags().GetInt("retry-on-404-search")
+			emptySearchDelay, _ := cmd.Flags().GetDuration("retry-on-404-search-delay")
 
-			// Validate blueprint or --all flag
-			if len(args) == 0 && !all {
-				return fmt.Errorf("❌ either provide a blueprint name or use --all flag. Usage: migrate <blueprint> or migrate --all")
+			// Validate blueprint, --all or --from-plan
+			if fromPlan != "" && (len(args) > 0 || all) {
+				return fmt.Errorf("❌ cannot use --from-plan with a blueprint argument or --all flag")
+			}
+			if fromPlan != "" && planFile != "" {
+				return fmt.Errorf("❌ cannot use both --from-plan and --plan-file")
+			}
+			if fromErrorFile != "" && (len(args) > 0 || all || fromPlan != "" || planFile != "" || output == "json") {
+				return fmt.Errorf("❌ cannot use --from-error-file with a blueprint argument, --all, --from-plan, --plan-file or --output json")
+			}
+			if fromErrorFile != "" && (preserveTitle || migrateRelations) {
+				return fmt.Errorf("❌ --preserve-title and --migrate-relations cannot be used with --from-error-file")
+			}
+			if len(args) == 0 && !all && fromPlan == "" && fromErrorFile == "" {
+				return fmt.Errorf("❌ either provide blueprint names or use --all flag. Usage: migrate <blueprint>..., migrate --all or migrate --from-plan <file>")
 			}
 			if len(args) > 0 && all {
 				return fmt.Errorf("❌ cannot use both blueprint argument and --all flag")
 			}
-
-			blueprint := ""
-			if len(args) > 0 {
-				blueprint = args[0]
+			if blueprintsCachePath != "" && !all {
+				return fmt.Errorf("❌ --blueprints-cache can only be used with --all")
+			}
+			if resumeFrom != "" && !all {
+				return fmt.Errorf("❌ --resume-from-blueprint can only be used with --all")
+			}
+			if planFile != "" && !dryRun {
+				return fmt.Errorf("❌ --plan-file can only be used with --dry-run")
+			}
+			if output != "table" && output != "json" {
+				return fmt.Errorf("❌ invalid --output %q, expected table or json", output)
+			}
+			if output == "json" && !dryRun {
+				return fmt.Errorf("❌ --output json can only be used with --dry-run")
+			}
+			if manifestFile != "" && dryRun {
+				return fmt.Errorf("❌ --manifest-file cannot be used with --dry-run, nothing is patched")
+			}
+			if output == "json" && fromPlan != "" {
+				return fmt.Errorf("❌ --output json cannot be used with --from-plan")
+			}
+			if (preserveTitle || migrateRelations) && fromPlan != "" {
+				return fmt.Errorf("❌ --preserve-title and --migrate-relations cannot be used with --from-plan")
+			}
+			if checkOrphans && (fromPlan != "" || fromErrorFile != "") {
+				return fmt.Errorf("❌ --check-orphans cannot be used with --from-plan or --from-error-file")
+			}
+			if allowOrphans && !checkOrphans {
+				return fmt.Errorf("❌ --allow-orphans can only be used with --check-orphans")
+			}
+			if maxFailures < 0 {
+				return fmt.Errorf("❌ --max-failures must not be negative")
+			}
+			if countConcurrency < 1 {
+				return fmt.Errorf("❌ --count-concurrency must be at least 1")
+			}
+			if deadline < 0 {
+				return fmt.Errorf("❌ --deadline must not be negative")
+			}
+			if confirmTimeout < 0 {
+				return fmt.Errorf("❌ --confirm-timeout must not be negative")
+			}
+			if batchDelay < 0 {
+				return fmt.Errorf("❌ --batch-delay must not be negative")
+			}
+			if emptySearchRetries < 0 {
+				return fmt.Errorf("❌ --retry-on-404-search must not be negative")
+			}
+			if noPromptBelow < 0 {
+				return fmt.Errorf("❌ --no-prompt-below must not be negative")
+			}
+			if blueprintRetries < 0 {
+				return fmt.Errorf("❌ --blueprint-retries must not be negative")
+			}
+			if integrationVersion != "" && !semverPattern.MatchString(integrationVersion) {
+				return fmt.Errorf("❌ invalid --integration-version %q, expected a semantic version like 1.2.3", integrationVersion)
 			}
 
 			// Validate required parameters
@@ -55,73 +162,273 @@ func NewMigrateCommand() *cobra.Command {
 				return fmt.Errorf("❌ missing required options: %v", missing)
 			}
 
+			// --deadline bounds the whole run, the request in flight and the delays included
+			ctx := cmd.Context()
+			if deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, startedAt.Add(deadline))
+				defer cancel()
+			}
+
 			// Create Port client
-			client := port.NewClient(portURL, clientID, clientSecret)
+			client := port.NewClient(portURL, clientID, clientSecret, append(clientOptions(cmd), port.WithContext(ctx))...)
 
-			// Get integration version
-			version, err := client.GetIntegrationVersion(newInstallID)
-			if err != nil {
-				return fmt.Errorf("failed to get integration version: %w", err)
+			// Get integration version, unless provided explicitly
+			version := integrationVersion
+			if version == "" {
+				fetched, err := client.GetIntegrationVersion(newInstallID)
+				if err != nil {
+					return fmt.Errorf("failed to get integration version: %w", err)
+				}
+				version = fetched
 			}
 
 			// Construct new datasource ID
-			newDatasourceID := fmt.Sprintf("port-ocean/github-ocean/%s/%s/exporter", version, newInstallID)
+			newDatasourceID := client.NewDatasource(version, newInstallID)
+
+			var cachedCounts map[string]int
+			if blueprintsCachePath != "" {
+				counts, err := readBlueprintsCache(blueprintsCachePath, oldInstallID, cacheMaxAge, cmd.ErrOrStderr())
+				if err != nil {
+					return err
+				}
+				cachedCounts = counts
+			}
 
 			// Create config
 			config := &models.Config{
-				PortAPIURL:        portURL,
-				ClientID:          clientID,
-				ClientSecret:      clientSecret,
-				OldInstallationID: oldInstallID,
-				NewInstallationID: newInstallID,
-			}
+				PortAPIURL:          portURL,
+				ClientID:            clientID,
+				ClientSecret:        clientSecret,
+				OldInstallationID:   oldInstallID,
+				NewInstallationID:   newInstallID,
+				PlanFile:            planFile,
+				Verify:              verify,
+				ResumeFromBlueprint: resumeFrom,
+				BlueprintCounts:     cachedCounts,
+				Output:              output,
+				BlueprintRetries:    blueprintRetries,
+				BlueprintRetryDelay: blueprintRetryDelay,
+				Strict:              strict,
+				PreserveTitle:       preserveTitle,
+				MigrateRelations:    migrateRelations,
+				MaxFailures:         maxFailures,
+				SummaryOnly:         summaryOnly && !verbose, // verbose keeps the per-batch detail
+				NoPromptBelow:       noPromptBelow,
+				EmptySearchRetries:  emptySearchRetries,
+				EmptySearchDelay:    emptySearchDelay,
+				ManifestFile:        manifestFile,
+				AllowUningested:     allowUningested,
+				CountConcurrency:    countConcurrency,
+				NoWarning:           noWarning,
 
+				MaxConsecutiveFailures: maxConsecutiveFailures,
+				JSONCompact:            jsonCompact(cmd),
+				VerifyIntegrity:        verifyIntegrity,
+				CheckOrphans:           checkOrphans,
+				AllowOrphans:           allowOrphans,
+				ConfirmTimeout:         confirmTimeout,
+				BatchDelay:             batchDelay,
+			}
 			// Create migrator
 			mig := migrator.NewMigrator(client, config)
+			mig.SetOutput(cmd.OutOrStdout(), cmd.ErrOrStderr())
+			mig.SetInput(cmd.InOrStdin())
+			mig.SetContext(ctx)
 
-		// If migrating "all", show blueprints with entity counts first
-		if all {
-			fmt.Println("📋 Blueprints to migrate:")
-			fmt.Println("NAME                              ENTITIES")
-			fmt.Println("──────────────────────────────────────────")
-			
-			blueprints, err := client.GetBlueprintsByDataSource(oldInstallID)
+			events, closeEvents, err := eventSink(cmd)
 			if err != nil {
-				return fmt.Errorf("failed to get blueprints: %w", err)
+				return err
 			}
-			
-			for _, bp := range blueprints {
-				entities, err := client.SearchOldEntitiesByBlueprint(bp, oldInstallID)
-				if err != nil {
-					fmt.Printf("%-33s ?\n", bp)
+			defer closeEvents()
+			mig.SetEventSink(events)
+
+			// finish hands the outcome to the error file, the report and the webhook
+			finish := func(stats *models.MigrationStats, err error) error {
+				if stats != nil {
+					stats.ErrorClasses = client.ErrorClasses()
+					if verbose {
+						printErrorClasses(cmd, stats.ErrorClasses)
+					}
+				}
+				writeErrorFile(cmd, errorFile, stats)
+				writeReport(cmd, reportFile, report.Report{
+					StartedAt:          startedAt,
+					FinishedAt:         time.Now(),
+					Command:            redactedInvocation(cmd, args),
+					DryRun:             dryRun,
+					IntegrationVersion: version,
+					OldDatasource:      port.OldDatasource(oldInstallID),
+					NewDatasource:      newDatasourceID,
+					Stats:              stats,
+				}, err)
+				notifyCompletion(cmd, notifyWebhook, stats, err)
+				notify.Emit(events, migrationFinishedEvent(stats, err, dryRun))
+				return err
+			}
+
+			// Execute a reviewed plan without rediscovering blueprints
+			if fromPlan != "" {
+				return finish(mig.MigrateFromPlan(fromPlan, newDatasourceID, dryRun))
+			}
+
+			// Re-run just the failures of an earlier run
+			if fromErrorFile != "" {
+				return finish(mig.MigrateFromErrorFile(fromErrorFile, newDatasourceID, dryRun))
+			}
+
+		// If migrating "all", show blueprints with entity counts first, the JSON plan has them already
+		if all && output != "json" {
+			fmt.Fprintln(cmd.ErrOrStderr(), "📋 Blueprints to migrate:")
+
+			// Counted by the migrator, which reuses the searches instead of repeating them
+			rows, err := mig.Preview(dryRun)
+			if err != nil {
+				return err
+			}
+
+			shown := rows
+			if previewLimit > 0 && !verbose && len(rows) > previewLimit {
+				shown = rows[:previewLimit]
+			}
+			names := make([]string, len(shown))
+			for i, row := range shown {
+				names[i] = row.Blueprint
+			}
+			width := textwidth.NameColumn(names)
+			printNameHeader(cmd.OutOrStdout(), width, "ENTITIES")
+			for _, row := range shown {
+				if row.Count < 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s ?\n", textwidth.Pad(row.Blueprint, width))
 					continue
 				}
-				count := len(entities)
-				
-				// Skip empty blueprints (no entities to migrate)
-				if count == 0 {
+				// The migrator skips it unless --allow-uningested
+				if row.Uningested {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s %-8d ⚠️  nothing on the new datasource yet\n", textwidth.Pad(row.Blueprint, width), row.Count)
 					continue
 				}
-				
-				fmt.Printf("%-33s %d\n", bp, count)
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %d\n", textwidth.Pad(row.Blueprint, width), row.Count)
 			}
-			fmt.Println()
-		}
-
-		// Determine if migrating single blueprint or all
-		var bp *string
-		if !all && blueprint != "" {
-			bp = &blueprint
+			if len(shown) < len(rows) {
+				fmt.Fprintf(cmd.OutOrStdout(), "... and %d more (use --verbose to show all)\n", len(rows)-len(shown))
+			}
+			fmt.Fprintln(cmd.ErrOrStderr())
 		}
 
-		// Run migration
-		_, err = mig.Migrate(newDatasourceID, bp, dryRun)
-		return err
+		// Run migration, no blueprint arguments with --all
+		return finish(mig.Migrate(newDatasourceID, args, dryRun))
 		},
 	}
 
 	cmd.Flags().Bool("dry-run", false, "Show what would be migrated without making changes")
 	cmd.Flags().Bool("all", false, "Migrate all blueprints with entities")
+	cmd.Flags().String("plan-file", "", "Write the planned changes for review, as a versioned JSON plan when the file ends in .json and as CSV otherwise (requires --dry-run)")
+	cmd.Flags().String("from-plan", "", "Migrate exactly the entities listed in a reviewed plan file, JSON or CSV")
+	cmd.Flags().Bool("verify", false, "After patching each blueprint, confirm the entities now have the new datasource")
+	cmd.Flags().Bool("verify-integrity", false, "After patching each blueprint, confirm the entities' title, properties and relations are unchanged, only their datasource")
+	cmd.Flags().String("resume-from-blueprint", "", "With --all, start from this blueprint in sorted order, skipping earlier ones")
+	cmd.Flags().Int("preview-limit", 0, "With --all, only preview the N blueprints with the most entities (0 = all)")
+	cmd.Flags().String("integration-version", "", "New integration version to build the datasource from, skips fetching it from Port")
+	cmd.Flags().String("blueprints-cache", "", "With --all, use the blueprints and counts cached by get-blueprints --cache-file")
+	cmd.Flags().Duration("cache-max-age", time.Hour, "Warn when the blueprints cache is older than this")
+	cmd.Flags().String("output", "table", "Output format: table or json, json emits the dry run plan without prompting (requires --dry-run)")
+	cmd.Flags().Int("blueprint-retries", 0, "Re-attempt a blueprint that failed to migrate up to N times before recording the failure")
+	cmd.Flags().Duration("blueprint-retry-delay", 30*time.Second, "Wait between blueprint retries")
+	cmd.Flags().Bool("strict", false, "Only migrate entities whose datasource is exactly the old installation's, skipping and reporting the rest")
+	cmd.Flags().Bool("preserve-title", false, "After patching, restore the old title of entities the new integration retitled")
+	cmd.Flags().Bool("migrate-relations", false, "After patching, restore the old relations of entities whose relations changed")
+	cmd.Flags().Int("max-failures", 0, "Abort the migration once more than N requests failed with a network error, 429 or 5xx (0 = no limit)")
+	cmd.Flags().Int("max-consecutive-failures", 0, "Abort the migration once N batches failed in a row, a sign of a systemic problem such as a bad datasource (0 = no limit)")
+	cmd.Flags().Duration("deadline", 0, "Stop the migration once the run has taken this long, e.g. 30m for a maintenance window, and exit with status 124 (0 = no deadline)")
+	cmd.Flags().Bool("summary-only", false, "Only print per-blueprint and overall results instead of a line per batch (--verbose restores them)")
+	cmd.Flags().Int("count-concurrency", 4, "Number of blueprints searched at once while counting entities before the confirmation prompt")
+	cmd.Flags().Int("retry-on-404-search", 0, "Search a blueprint again up to N times when it comes back empty or 404, for Port's eventual consistency right after an integration change")
+	cmd.Flags().Duration("retry-on-404-search-delay", 10*time.Second, "Wait before searching an empty blueprint again")
+	cmd.Flags().Bool("no-warning", false, "Leave out the 'cannot be undone' warning banner, the confirmation prompt is still shown")
+	cmd.Flags().Duration("batch-delay", 0, "Pause between bulk patch batches, e.g. 500ms, to pace the requests on rate-sensitive tenants (0 = no pause)")
+	cmd.Flags().Duration("confirm-timeout", 0, "Cancel the migration when 'yes' wasn't typed within this long, e.g. so an unattended run doesn't wait forever (0 = wait)")
+	cmd.Flags().Int("no-prompt-below", 0, "Proceed without the 'yes' confirmation when fewer than N entities are affected (0 = always prompt)")
+	cmd.Flags().String("manifest-file", "", "Append each migrated blueprint's patched entities and their old datasource to this JSONL file, rerunning with it skips completed blueprints")
+	cmd.Flags().Bool("allow-uningested", false, "Migrate blueprints that have no entities on the new datasource yet instead of skipping them, their entities may end up orphaned")
+	cmd.Flags().Bool("check-orphans", false, "Before migrating, list per blueprint the old entities with no counterpart on the new datasource and abort if there are any")
+	cmd.Flags().Bool("allow-orphans", false, "With --check-orphans, migrate despite the entities it lists")
+	cmd.Flags().String("error-file", "", "When entities fail to migrate, write them, their target datasource and the errors to this JSON file")
+	cmd.Flags().String("from-error-file", "", "Re-run just the failures recorded by --error-file")
+	cmd.Flags().String("report-file", "", "Write a Markdown report of the migration for change tickets: counts, failures, datasources and the command")
+	cmd.Flags().String("notify-webhook", "", "POST a JSON summary to this URL when the migration finishes")
+	cmd.Flags().String("audit-log", "", "Append a JSON line per migration event (started, blueprint done, finished) to this file")
+	cmd.Flags().String("event-webhook", "", "POST each migration event as JSON to this URL as it happens")
 
 	return cmd
 }
+
+// semverPattern matches a semantic version such as 1.2.3 or 1.2.3-beta.1
+var semverPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// writeErrorFile writes the migration's failures for a re-run with --from-error-file, if there are any
+func writeErrorFile(cmd *cobra.Command, path string, stats *models.MigrationStats) {
+	if path == "" || stats == nil || len(stats.Failures) == 0 {
+		return
+	}
+
+	if err := migrator.WriteErrorFile(path, stats.Failures); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  %v\n", err)
+		return
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "📝 Wrote %d failures to %s, re-run them with --from-error-file %s\n", len(stats.Failures), path, path)
+}
+
+// writeReport writes the Markdown migration report, a failed report doesn't fail the migration
+func writeReport(cmd *cobra.Command, path string, r report.Report, migrationErr error) {
+	if path == "" {
+		return
+	}
+
+	if migrationErr != nil {
+		r.Error = migrationErr.Error()
+	}
+	if err := report.Write(path, r, secretValues(cmd)...); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  %v\n", err)
+		return
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "📝 Wrote the migration report to %s\n", path)
+}
+
+// printErrorClasses prints how many requests failed per class, to tell whether to raise retries,
+// lower the concurrency or fix the credentials
+func printErrorClasses(cmd *cobra.Command, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+
+	var parts []string
+	for _, class := range port.ErrorClassNames() {
+		if counts[class] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", class, counts[class]))
+		}
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "📉 Failed requests by class: %s\n", strings.Join(parts, ", "))
+}
+
+// migrationFinishedEvent is the last event of a migration, with its outcome and the final stats
+func migrationFinishedEvent(stats *models.MigrationStats, migrationErr error, dryRun bool) notify.Event {
+	data := map[string]interface{}{
+		"dryRun": dryRun,
+		"stats":  stats,
+	}
+	if migrationErr != nil {
+		data["error"] = migrationErr.Error()
+	}
+	return notify.NewEvent(notify.EventMigrationFinished, "", data)
+}
+
+// notifyCompletion posts the migration outcome to the webhook, a failed notification doesn't fail the migration
+func notifyCompletion(cmd *cobra.Command, url string, stats *models.MigrationStats, migrationErr error) {
+	if url == "" {
+		return
+	}
+
+	if err := notify.SendWebhook(url, stats, migrationErr, secretValues(cmd)...); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  Failed to send webhook notification: %v\n", err)
+	}
+}
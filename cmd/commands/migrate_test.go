@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omby8888/port-github-migrator/internal/port"
+	"github.com/omby8888/port-github-migrator/internal/port/porttest"
+)
+
+func TestMigrateAllPreview(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	srv.SetIntegrationVersion("67890", "1.0.0")
+	srv.AddDataSource("12345", "githubRepository", "githubIssue")
+	newDatasource := srv.Client().NewDatasource("1.0.0", "67890")
+	srv.AddEntities("githubRepository",
+		port.Entity{Identifier: "a", Datasource: port.OldDatasource("12345")},
+		port.Entity{Identifier: "b", Datasource: port.OldDatasource("12345")},
+		port.Entity{Identifier: "c", Datasource: newDatasource},
+	)
+	srv.AddEntities("githubIssue", port.Entity{Identifier: "1", Datasource: port.OldDatasource("12345")})
+
+	var stdout bytes.Buffer
+	root := NewRootCommand()
+	root.SetOut(&stdout)
+	root.SetErr(&bytes.Buffer{})
+	root.SetIn(strings.NewReader(""))
+	root.SetArgs([]string{"migrate", "--all", "--dry-run", "--port-url", srv.URL,
+		"--client-id", porttest.ClientID, "--client-secret", porttest.ClientSecret,
+		"--old-installation-id", "12345", "--new-installation-id", "67890"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	lines := strings.Split(stdout.String(), "\n")
+	var rows []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "github") {
+			rows = append(rows, strings.Join(strings.Fields(line), " "))
+		}
+	}
+	want := []string{"githubRepository 2", "githubIssue 1 ⚠️ nothing on the new datasource yet"}
+	if strings.Join(rows, "\n") != strings.Join(want, "\n") {
+		t.Errorf("preview rows:\n%s\nwant:\n%s\nfull output:\n%s", strings.Join(rows, "\n"), strings.Join(want, "\n"), stdout.String())
+	}
+	// The preview's searches are reused by the migration
+	if got := srv.Requests("POST /v1/blueprints/githubRepository/entities/search"); got != 1 {
+		t.Errorf("searched githubRepository %d times, want once", got)
+	}
+}
+
+func TestMigrateAllPreviewAlignment(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	srv.SetIntegrationVersion("67890", "1.0.0")
+	long := "githubRepositoryDeploymentEnvironmentProtectionRule"
+	srv.AddDataSource("12345", long, "🚀deployments")
+	srv.AddEntities(long,
+		port.Entity{Identifier: "a", Datasource: port.OldDatasource("12345")},
+		port.Entity{Identifier: "b", Datasource: srv.Client().NewDatasource("1.0.0", "67890")},
+	)
+	srv.AddEntities("🚀deployments", port.Entity{Identifier: "1", Datasource: port.OldDatasource("12345")})
+
+	var stdout bytes.Buffer
+	root := NewRootCommand()
+	root.SetOut(&stdout)
+	root.SetErr(&bytes.Buffer{})
+	root.SetIn(strings.NewReader(""))
+	root.SetArgs([]string{"migrate", "--all", "--dry-run", "--port-url", srv.URL,
+		"--client-id", porttest.ClientID, "--client-secret", porttest.ClientSecret,
+		"--old-installation-id", "12345", "--new-installation-id", "67890"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	want := `NAME                                                ENTITIES
+────────────────────────────────────────────────────────────
+githubRepositoryDeploymentEnvironmentProtectionRule 1
+🚀deployments                                       1        ⚠️  nothing on the new datasource yet
+`
+	if !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("preview:\n%s\nwant it to start with:\n%s", stdout.String(), want)
+	}
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+func NewResolveDatasourceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "resolve-datasource",
+		Short:        "Print the new integration's datasource, for scripting",
+		Long:         "Fetch the new integration's version and print just the datasource entities are migrated to, e.g. port-ocean/github-ocean/<version>/<new-installation-id>/exporter.",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			portURL, _ := cmd.Flags().GetString("port-url")
+			clientID, _ := cmd.Flags().GetString("client-id")
+			clientSecret, _ := cmd.Flags().GetString("client-secret")
+			newInstallID, _ := cmd.Flags().GetString("new-installation-id")
+
+			// Validate required parameters
+			var missing []string
+			if clientID == "" {
+				missing = append(missing, "--client-id")
+			}
+			if clientSecret == "" {
+				missing = append(missing, "--client-secret")
+			}
+			if newInstallID == "" {
+				missing = append(missing, "--new-installation-id")
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("❌ missing required options: %v", missing)
+			}
+
+			client := port.NewClient(portURL, clientID, clientSecret, clientOptions(cmd)...)
+
+			version, err := client.GetIntegrationVersion(newInstallID)
+			if err != nil {
+				return fmt.Errorf("failed to get integration version: %w", err)
+			}
+			if version == "" {
+				return fmt.Errorf("❌ integration %s has no version, is it installed and running?", newInstallID)
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), client.NewDatasource(version, newInstallID))
+			return nil
+		},
+	}
+
+	return cmd
+}
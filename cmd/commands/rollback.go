@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/omby8888/port-github-migrator/internal/migrator"
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+func NewRollbackCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback [blueprint]",
+		Short: "Re-point migrated entities back to the old datasource",
+		Long: `Re-point entities on the new datasource back to the old datasource. The entities are listed first, with counts and an identifier preview, and the old installation ID has to be typed to proceed. --dry-run only lists them.
+
+With --manifest-file, the candidates are the entities recorded by migrate --manifest-file, each going back to the datasource recorded for it.
+
+With --from-plan, exactly the entities of a plan written by rollback --dry-run --plan-file are rolled back, those no longer on the new datasource the plan recorded are skipped.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			portURL, _ := cmd.Flags().GetString("port-url")
+			clientID, _ := cmd.Flags().GetString("client-id")
+			clientSecret, _ := cmd.Flags().GetString("client-secret")
+			oldInstallID, _ := cmd.Flags().GetString("old-installation-id")
+			newInstallID, _ := cmd.Flags().GetString("new-installation-id")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			all, _ := cmd.Flags().GetBool("all")
+			manifestFile, _ := cmd.Flags().GetString("manifest-file")
+			output, _ := cmd.Flags().GetString("output")
+			planFile, _ := cmd.Flags().GetString("plan-file")
+			confirmTimeout, _ := cmd.Flags().GetDuration("confirm-timeout")
+			fromPlan, _ := cmd.Flags().GetString("from-plan")
+
+			if fromPlan != "" && (len(args) > 0 || all || manifestFile != "" || planFile != "") {
+				return fmt.Errorf("❌ cannot use --from-plan with a blueprint argument, --all, --manifest-file or --plan-file")
+			}
+			if fromPlan == "" && all == (len(args) > 0) {
+				return fmt.Errorf("❌ either a blueprint argument, --all or --from-plan is required")
+			}
+			if len(args) > 1 {
+				return fmt.Errorf("❌ only one blueprint argument is allowed")
+			}
+			if output != "table" && output != "json" {
+				return fmt.Errorf("❌ invalid --output %q, expected table or json", output)
+			}
+			if output == "json" && !dryRun {
+				return fmt.Errorf("❌ --output json can only be used with --dry-run")
+			}
+			if planFile != "" && !dryRun {
+				return fmt.Errorf("❌ --plan-file can only be used with --dry-run")
+			}
+			if confirmTimeout < 0 {
+				return fmt.Errorf("❌ --confirm-timeout must not be negative")
+			}
+
+			// Validate required parameters
+			var missing []string
+			if clientID == "" {
+				missing = append(missing, "--client-id")
+			}
+			if clientSecret == "" {
+				missing = append(missing, "--client-secret")
+			}
+			if oldInstallID == "" {
+				missing = append(missing, "--old-installation-id")
+			}
+			if newInstallID == "" {
+				missing = append(missing, "--new-installation-id")
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("❌ missing required options: %v", missing)
+			}
+
+			client := port.NewClient(portURL, clientID, clientSecret, clientOptions(cmd)...)
+			mig := migrator.NewMigrator(client, &models.Config{
+				OldInstallationID: oldInstallID,
+				NewInstallationID: newInstallID,
+				ManifestFile:      manifestFile,
+				PlanFile:          planFile,
+				Output:            output,
+				JSONCompact:       jsonCompact(cmd),
+				ConfirmTimeout:    confirmTimeout,
+			})
+			mig.SetOutput(cmd.OutOrStdout(), cmd.ErrOrStderr())
+			mig.SetInput(cmd.InOrStdin())
+
+			if fromPlan != "" {
+				stats, err := mig.RollbackFromPlan(fromPlan, dryRun)
+				if err != nil {
+					return err
+				}
+				if stats.FailedBatches > 0 {
+					return fmt.Errorf("❌ %d blueprints failed to roll back", stats.FailedBatches)
+				}
+				return nil
+			}
+
+			blueprints := args
+			if all {
+				discovered, err := mig.RollbackBlueprints()
+				if err != nil {
+					return err
+				}
+				blueprints = discovered
+			}
+
+			if dryRun {
+				_, err := mig.RollbackDryRun(blueprints)
+				return err
+			}
+
+			stats, err := mig.Rollback(blueprints)
+			if err != nil {
+				return err
+			}
+			if stats.FailedBatches > 0 {
+				return fmt.Errorf("❌ %d blueprints failed to roll back", stats.FailedBatches)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Show what would be rolled back without making changes")
+	cmd.Flags().Bool("all", false, "Roll back all blueprints, those in --manifest-file or else the old installation's")
+	cmd.Flags().String("manifest-file", "", "Only roll back the entities recorded by migrate --manifest-file, to the datasource recorded for each")
+	cmd.Flags().String("output", "table", "Output format: table or json (requires --dry-run)")
+	cmd.Flags().String("plan-file", "", "Write the planned changes for review, as a versioned JSON plan when the file ends in .json and as CSV otherwise (requires --dry-run)")
+	cmd.Flags().String("from-plan", "", "Roll back exactly the entities listed in a plan written by rollback --dry-run --plan-file, JSON or CSV")
+	cmd.Flags().Duration("confirm-timeout", 0, "Cancel the rollback when the installation ID wasn't typed within this long (0 = wait)")
+
+	return cmd
+}
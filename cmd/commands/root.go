@@ -1,17 +1,56 @@
 package commands
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/omby8888/port-github-migrator/internal/notify"
+	"github.com/omby8888/port-github-migrator/internal/port"
+	"github.com/omby8888/port-github-migrator/internal/redact"
 )
 
+// ExitTimeout is the exit status of a run stopped at its --deadline, the one timeout(1) uses
+const ExitTimeout = 124
+
+// ExitCode returns the process exit status for the error a command returned
+func ExitCode(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ExitTimeout
+	}
+	return 1
+}
+
 func NewRootCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:          "port-github-migrator",
 		Short:        "Migrate Ownership of Port entities from GitHub App to GitHub Ocean",
 		Long:         `A tool to safely migrate Ownership of Port entities from the legacy GitHub App integration to the new GitHub Ocean integration.`,
 		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Everything the commands print goes through the redaction, so no output can leak a secret.
+			// Set on the root, the commands inherit its writers and it prints the returned error.
+			secrets := secretValues(cmd)
+			root := cmd.Root()
+			root.SetOut(redact.Writer(root.OutOrStdout(), secrets...))
+			root.SetErr(redact.Writer(root.ErrOrStderr(), secrets...))
+
+			headers, _ := cmd.Flags().GetStringArray("header")
+			if _, err := parseHeaders(headers); err != nil {
+				return err
+			}
+			searchPath, _ := cmd.Flags().GetString("search-path")
+			if err := port.ValidateSearchPath(searchPath); err != nil {
+				return fmt.Errorf("❌ invalid --search-path: %w", err)
+			}
+			return nil
+		},
 	}
 
 	// Hide the auto-generated completion and help commands
@@ -23,16 +62,146 @@ func NewRootCommand() *cobra.Command {
 	cmd.PersistentFlags().String("old-installation-id", getEnv("OLD_INSTALLATION_ID", ""), "Old GitHub App Installation ID")
 	cmd.PersistentFlags().String("new-installation-id", getEnv("NEW_INSTALLATION_ID", ""), "New GitHub Ocean Installation ID")
 	cmd.PersistentFlags().Bool("verbose", false, "Enable verbose logging")
+	cmd.PersistentFlags().Float64("max-rps", 0, "Maximum Port API requests per second (0 = unlimited)")
+	cmd.PersistentFlags().StringArray("header", nil, "Add a header to every Port API request, e.g. 'X-Request-Source: migrator'. Repeatable")
+	cmd.PersistentFlags().String("new-datasource-kind", port.DefaultNewDatasourceKind, "Kind of the new Ocean integration's datasource, for non-standard setups")
+	cmd.PersistentFlags().String("new-datasource-suffix", port.DefaultNewDatasourceSuffix, "Suffix of the new Ocean integration's datasource after the installation ID, for non-standard setups")
+	cmd.PersistentFlags().String("api-version", port.DefaultAPIVersion, "Port API version prefix of the endpoint paths, for self-hosted or newer Port versions")
+	cmd.PersistentFlags().String("search-path", port.DefaultSearchPath, "Entity search path after the API version, with a {blueprint} placeholder")
+	cmd.PersistentFlags().Bool("json-compact", false, "Write --output json on a single line instead of indented (default: compact when stdout isn't a terminal)")
+	cmd.PersistentFlags().String("user-agent", "", "User-Agent of every Port API request (default: port-github-migrator/<version>)")
+	cmd.PersistentFlags().Int("max-patch-body-bytes", port.DefaultMaxPatchBodySize, "Split bulk patches whose body exceeds this size in bytes (0 = never split)")
 
 	cmd.AddCommand(
 		NewMigrateCommand(),
 		NewGetBlueprintsCommand(),
+		NewCoverageCommand(),
 		NewGetDiffCommand(),
+		NewGetEntityDiffCommand(),
+		NewGetDatasourcesCommand(),
+		NewDatasourceSnapshotCommand(),
+		NewResolveDatasourceCommand(),
+		NewRollbackCommand(),
+		NewConfigCommand(),
+		NewValidateCommand(),
 	)
 
 	return cmd
 }
 
+// clientOptions builds the Port client options from the global flags
+func clientOptions(cmd *cobra.Command) []port.Option {
+	maxRPS, _ := cmd.Flags().GetFloat64("max-rps")
+	maxPatchBodyBytes, _ := cmd.Flags().GetInt("max-patch-body-bytes")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	headerValues, _ := cmd.Flags().GetStringArray("header")
+	newDatasourceKind, _ := cmd.Flags().GetString("new-datasource-kind")
+	newDatasourceSuffix, _ := cmd.Flags().GetString("new-datasource-suffix")
+	apiVersion, _ := cmd.Flags().GetString("api-version")
+	searchPath, _ := cmd.Flags().GetString("search-path")
+	userAgent, _ := cmd.Flags().GetString("user-agent")
+	if userAgent == "" {
+		userAgent = defaultUserAgent(cmd)
+	}
+
+	// Already validated before the command ran
+	headers, _ := parseHeaders(headerValues)
+
+	opts := []port.Option{
+		port.WithMaxRPS(maxRPS),
+		port.WithMaxPatchBodySize(maxPatchBodyBytes),
+		port.WithHeaders(headers),
+		port.WithNewDatasource(newDatasourceKind, newDatasourceSuffix),
+		port.WithAPIVersion(apiVersion),
+		port.WithSearchPath(searchPath),
+		port.WithUserAgent(userAgent),
+	}
+
+	if verbose {
+		log := cmd.ErrOrStderr()
+		opts = append(opts, port.WithLog(log))
+		opts = append(opts, port.WithSearchProgress(func(blueprintID string, fetched, pages int) {
+			fmt.Fprintf(log, "   🔍 %s: fetched %d entities (%d pages)\n", blueprintID, fetched, pages)
+		}))
+	}
+
+	return opts
+}
+
+// defaultUserAgent identifies the tool and its version, port-github-migrator/<version>
+func defaultUserAgent(cmd *cobra.Command) string {
+	version := cmd.Root().Version
+	if version == "" {
+		version = "dev"
+	}
+	return port.UserAgentProduct + "/" + version
+}
+
+// eventSink builds the sink of --audit-log and --event-webhook, nil when neither is set. A failed
+// event is only warned about, it doesn't fail the command. The returned func closes the audit log.
+func eventSink(cmd *cobra.Command) (notify.EventSink, func(), error) {
+	auditLog, _ := cmd.Flags().GetString("audit-log")
+	eventWebhook, _ := cmd.Flags().GetString("event-webhook")
+
+	var sinks notify.MultiSink
+	closeSinks := func() {}
+	if auditLog != "" {
+		fileSink, err := notify.NewFileSink(auditLog, secretValues(cmd)...)
+		if err != nil {
+			return nil, nil, err
+		}
+		sinks = append(sinks, fileSink)
+		closeSinks = func() { fileSink.Close() }
+	}
+	if eventWebhook != "" {
+		sinks = append(sinks, notify.NewHTTPSink(eventWebhook, secretValues(cmd)...))
+	}
+	if len(sinks) == 0 {
+		return nil, closeSinks, nil
+	}
+	return warningSink{sinks: sinks, log: cmd.ErrOrStderr()}, closeSinks, nil
+}
+
+// warningSink warns about events that couldn't be sent instead of failing
+type warningSink struct {
+	sinks notify.EventSink
+	log   io.Writer
+}
+
+func (w warningSink) Emit(event notify.Event) error {
+	if err := w.sinks.Emit(event); err != nil {
+		fmt.Fprintf(w.log, "⚠️  Failed to send the %s event: %v\n", event.Type, err)
+	}
+	return nil
+}
+
+// jsonCompact reports whether --output json is written on a single line: as set by --json-compact,
+// or else when stdout is piped rather than a terminal
+func jsonCompact(cmd *cobra.Command) bool {
+	if cmd.Flags().Changed("json-compact") {
+		compact, _ := cmd.Flags().GetBool("json-compact")
+		return compact
+	}
+	return !isTerminal(cmd.OutOrStdout())
+}
+
+// parseHeaders parses 'Key: Value' header flags
+func parseHeaders(values []string) (http.Header, error) {
+	headers := make(http.Header)
+	for _, value := range values {
+		parts := strings.SplitN(value, ":", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("❌ invalid --header %q, expected 'Key: Value'", value)
+		}
+		if http.CanonicalHeaderKey(key) == "Authorization" {
+			return nil, fmt.Errorf("❌ --header cannot override the Authorization header")
+		}
+		headers.Add(key, strings.TrimSpace(parts[1]))
+	}
+	return headers, nil
+}
+
 func getEnv(key, defaultVal string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -40,3 +209,26 @@ func getEnv(key, defaultVal string) string {
 	return defaultVal
 }
 
+// readLines reads the non-empty, non-comment lines of a file
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/omby8888/port-github-migrator/internal/redact"
+	"github.com/spf13/cobra"
+)
+
+// openTerminal returns a character device standing in for a terminal
+func openTerminal(t *testing.T) *os.File {
+	t.Helper()
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skipf("no %s: %v", os.DevNull, err)
+	}
+	t.Cleanup(func() { f.Close() })
+	if info, err := f.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		t.Skipf("%s isn't a character device", os.DevNull)
+	}
+	return f
+}
+
+func TestIsTerminalThroughRedaction(t *testing.T) {
+	terminal := openTerminal(t)
+
+	tests := []struct {
+		name string
+		w    func() io.Writer
+		want bool
+	}{
+		{"terminal", func() io.Writer { return terminal }, true},
+		{"redacted terminal", func() io.Writer { return redact.Writer(terminal, "secret-value") }, true},
+		{"redacted twice", func() io.Writer { return redact.Writer(redact.Writer(terminal)) }, true},
+		{"buffer", func() io.Writer { return &bytes.Buffer{} }, false},
+		{"redacted buffer", func() io.Writer { return redact.Writer(&bytes.Buffer{}) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTerminal(tt.w()); got != tt.want {
+				t.Errorf("isTerminal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRedactedOutputKeepsTerminalDefaults runs a command, so its output is wrapped in the redaction,
+// and checks what depends on stdout being a terminal: --watch and the --json-compact default
+func TestRedactedOutputKeepsTerminalDefaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		terminal    bool
+		wantCompact bool
+	}{
+		{"terminal", true, false},
+		{"piped", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := NewRootCommand()
+			if tt.terminal {
+				root.SetOut(openTerminal(t))
+			} else {
+				root.SetOut(&bytes.Buffer{})
+			}
+			root.SetErr(&bytes.Buffer{})
+
+			var ran *cobra.Command
+			probe := &cobra.Command{
+				Use:  "probe",
+				RunE: func(cmd *cobra.Command, args []string) error { ran = cmd; return nil },
+			}
+			root.AddCommand(probe)
+			root.SetArgs([]string{"probe", "--client-secret", "client-secret-value"})
+			if err := root.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := isTerminal(ran.OutOrStdout()); got != tt.terminal {
+				t.Errorf("isTerminal(stdout) = %v, want %v, --watch depends on it", got, tt.terminal)
+			}
+			if got := jsonCompact(ran); got != tt.wantCompact {
+				t.Errorf("jsonCompact() = %v, want %v", got, tt.wantCompact)
+			}
+		})
+	}
+}
+
+func TestCommandErrorsAreRedacted(t *testing.T) {
+	const secret = "client-secret-value"
+	var stderr bytes.Buffer
+	root := NewRootCommand()
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&stderr)
+	// Nothing listens on port 1, the connection error quotes the URL with the secret in it
+	root.SetArgs([]string{"get-blueprints", "--old-installation-id", "1", "--port-url", "http://127.0.0.1:1/" + secret, "--client-id", "id", "--client-secret", secret})
+	if err := root.Execute(); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+
+	if strings.Contains(stderr.String(), secret) {
+		t.Errorf("stderr contains the secret: %s", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "clie****") {
+		t.Errorf("stderr doesn't contain the masked secret: %s", stderr.String())
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"failure", errors.New("❌ missing required options"), 1},
+		{"deadline", fmt.Errorf("stopped at the --deadline: %w", context.DeadlineExceeded), ExitTimeout},
+		{"request cut off at the deadline", fmt.Errorf("failed to patch batch: %w", &url.Error{Op: "Patch", URL: "https://api.getport.io", Err: context.DeadlineExceeded}), ExitTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/omby8888/port-github-migrator/internal/jsonout"
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+// validationCheck is the outcome of a single validate check
+type validationCheck struct {
+	Name         string `json:"name"`
+	Passed       bool   `json:"passed"`
+	Inconclusive bool   `json:"inconclusive,omitempty"` // neither passed nor failed, doesn't fail the validation
+	Error        string `json:"error,omitempty"`
+}
+
+// validationReport is the JSON output of the validate command
+type validationReport struct {
+	Auth               string            `json:"auth"` // "ok" or "failed"
+	IntegrationVersion string            `json:"integrationVersion,omitempty"`
+	BlueprintsFound    int               `json:"blueprintsFound"`
+	Checks             []validationCheck `json:"checks"`
+	Passed             bool              `json:"passed"`
+}
+
+// record adds a check outcome to the report
+func (r *validationReport) record(name string, err error) bool {
+	check := validationCheck{Name: name, Passed: err == nil, Inconclusive: errors.Is(err, port.ErrInconclusive)}
+	if err != nil {
+		check.Error = err.Error()
+		if !check.Inconclusive {
+			r.Passed = false
+		}
+	}
+	r.Checks = append(r.Checks, check)
+	return err == nil
+}
+
+func NewValidateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "validate",
+		Short:        "Check the configuration and Port access before migrating",
+		Long:         "Check that the credentials authenticate, the new integration exists, the old installation manages blueprints and the credentials may patch entities.",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			portURL, _ := cmd.Flags().GetString("port-url")
+			clientID, _ := cmd.Flags().GetString("client-id")
+			clientSecret, _ := cmd.Flags().GetString("client-secret")
+			oldInstallID, _ := cmd.Flags().GetString("old-installation-id")
+			newInstallID, _ := cmd.Flags().GetString("new-installation-id")
+			output, _ := cmd.Flags().GetString("output")
+
+			if output != "table" && output != "json" {
+				return fmt.Errorf("❌ invalid --output %q, expected table or json", output)
+			}
+
+			report := &validationReport{Auth: "failed", Passed: true}
+
+			// Validate required parameters
+			var missing []string
+			if clientID == "" {
+				missing = append(missing, "--client-id")
+			}
+			if clientSecret == "" {
+				missing = append(missing, "--client-secret")
+			}
+			if oldInstallID == "" {
+				missing = append(missing, "--old-installation-id")
+			}
+			if newInstallID == "" {
+				missing = append(missing, "--new-installation-id")
+			}
+			var configErr error
+			if len(missing) > 0 {
+				configErr = fmt.Errorf("missing required options: %v", missing)
+			}
+
+			if report.record("config", configErr) {
+				client := port.NewClient(portURL, clientID, clientSecret, clientOptions(cmd)...)
+
+				if report.record("auth", client.Authenticate()) {
+					report.Auth = "ok"
+
+					version, err := client.GetIntegrationVersion(newInstallID)
+					if report.record("integration", err) {
+						report.IntegrationVersion = version
+					}
+
+					blueprints, err := client.GetBlueprintsByDataSource(oldInstallID)
+					if report.record("blueprints", err) {
+						report.BlueprintsFound = len(blueprints)
+					}
+
+					// Read-only credentials pass every check above but can't migrate. The probe patches
+					// no entities, so it needs a blueprint but changes nothing.
+					if len(blueprints) > 0 {
+						report.record("write", client.CheckWriteAccess(blueprints[0], port.OldDatasource(oldInstallID)))
+					}
+				}
+			}
+
+			if output == "json" {
+				encoder := jsonout.NewEncoder(cmd.OutOrStdout(), jsonCompact(cmd))
+				if err := encoder.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				for _, check := range report.Checks {
+					if check.Passed {
+						fmt.Fprintf(cmd.OutOrStdout(), "✅ %s\n", check.Name)
+						continue
+					}
+					if check.Inconclusive {
+						fmt.Fprintf(cmd.OutOrStdout(), "⚠️  %s: %s\n", check.Name, check.Error)
+						continue
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "❌ %s: %s\n", check.Name, check.Error)
+				}
+				if report.IntegrationVersion != "" {
+					fmt.Fprintf(cmd.OutOrStdout(), "   Integration version: %s\n", report.IntegrationVersion)
+				}
+				if report.BlueprintsFound > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "   Blueprints found: %d\n", report.BlueprintsFound)
+				}
+			}
+
+			if !report.Passed {
+				return fmt.Errorf("❌ validation failed")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("output", "table", "Output format: table or json")
+
+	return cmd
+}
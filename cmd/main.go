@@ -3,7 +3,6 @@ package main
 import (
 	"os"
 
-	"github.com/joho/godotenv"
 	"github.com/omby8888/port-github-migrator/cmd/commands"
 )
 
@@ -11,13 +10,13 @@ const Version = "1.0.0"
 
 func main() {
 	// Load .env file
-	_ = godotenv.Load()
+	commands.LoadEnvFile()
 
 	rootCmd := commands.NewRootCommand()
 	rootCmd.Version = Version
 
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(commands.ExitCode(err))
 	}
 }
 
@@ -0,0 +1,124 @@
+// Package confirm asks the user to type a phrase before a destructive change, each command
+// configuring its own prompt
+package confirm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultPhrase is what has to be typed when a prompt doesn't set its own
+const DefaultPhrase = "yes"
+
+// Prompt is the confirmation a destructive command asks for
+type Prompt struct {
+	Text    string        // shown before asking, "Type '<phrase>' to proceed" when empty
+	Phrase  string        // what has to be typed to proceed, DefaultPhrase when empty
+	Timeout time.Duration // decline when nothing was typed in time, 0 waits forever
+	Skip    bool          // proceed without asking, e.g. for small changes
+}
+
+// Ask prints the prompt to out and reads a line from in, returning whether the phrase was typed.
+// A skipped prompt proceeds without reading anything.
+func (p Prompt) Ask(in io.Reader, out io.Writer) bool {
+	if p.Skip {
+		return true
+	}
+
+	fmt.Fprintf(out, "\n%s: ", p.text())
+
+	if p.Timeout <= 0 {
+		line, _ := bufio.NewReader(in).ReadString('\n')
+		return p.Matches(line)
+	}
+
+	line, err := readLineWithin(in, p.Timeout)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		fmt.Fprintf(out, "\n⏰ Nothing was typed within %s\n", p.Timeout)
+		return false
+	}
+	return p.Matches(line)
+}
+
+// deadlineReader is an input whose reads can time out, such as a pipe or a terminal
+type deadlineReader interface {
+	io.Reader
+	SetReadDeadline(t time.Time) error
+}
+
+// readLineWithin reads a line, failing with os.ErrDeadlineExceeded when none came within timeout.
+// The read is cancelled at the timeout when in supports deadlines, stdin is reopened to support them.
+// Other readers are read in the background, a read still blocked at the timeout is left behind.
+func readLineWithin(in io.Reader, timeout time.Duration) (string, error) {
+	if r, release, ok := timedReader(in); ok {
+		defer release()
+		if err := r.SetReadDeadline(time.Now().Add(timeout)); err == nil {
+			return bufio.NewReader(r).ReadString('\n')
+		}
+	}
+
+	lines := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(in).ReadString('\n')
+		lines <- line
+	}()
+	select {
+	case line := <-lines:
+		return line, nil
+	case <-time.After(timeout):
+		return "", os.ErrDeadlineExceeded
+	}
+}
+
+// timedReader returns in as a reader supporting deadlines and a function releasing it, false when
+// there is none. A terminal or pipe on stdin doesn't support them, as it's in blocking mode, so it is
+// reopened on Linux, where that opens it anew. Elsewhere reopening could share the blocking mode
+// with the shell.
+func timedReader(in io.Reader) (deadlineReader, func(), bool) {
+	if r, ok := in.(deadlineReader); ok && r.SetReadDeadline(time.Time{}) == nil {
+		return r, func() { r.SetReadDeadline(time.Time{}) }, true
+	}
+
+	f, ok := in.(*os.File)
+	if !ok || f != os.Stdin || runtime.GOOS != "linux" {
+		return nil, nil, false
+	}
+	info, err := f.Stat()
+	if err != nil || info.Mode()&(os.ModeCharDevice|os.ModeNamedPipe) == 0 {
+		return nil, nil, false
+	}
+	reopened, err := os.Open("/proc/self/fd/0")
+	if err != nil {
+		return nil, nil, false
+	}
+	if reopened.SetReadDeadline(time.Time{}) != nil {
+		reopened.Close()
+		return nil, nil, false
+	}
+	return reopened, func() { reopened.Close() }, true
+}
+
+// Matches reports whether the typed input is the phrase, ignoring surrounding whitespace
+func (p Prompt) Matches(input string) bool {
+	return strings.TrimSpace(input) == p.phrase()
+}
+
+func (p Prompt) phrase() string {
+	if p.Phrase == "" {
+		return DefaultPhrase
+	}
+	return p.Phrase
+}
+
+func (p Prompt) text() string {
+	if p.Text == "" {
+		return fmt.Sprintf("Type '%s' to proceed", p.phrase())
+	}
+	return p.Text
+}
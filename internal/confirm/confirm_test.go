@@ -0,0 +1,98 @@
+package confirm
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		phrase string
+		input  string
+		want   bool
+	}{
+		{"default phrase", "", "yes\n", true},
+		{"surrounding whitespace", "", "  yes \r\n", true},
+		{"case matters", "", "YES\n", false},
+		{"prefix", "", "y\n", false},
+		{"nothing typed", "", "", false},
+		{"custom phrase", "12345", "12345\n", true},
+		{"default phrase when a custom one is set", "12345", "yes\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Prompt{Phrase: tt.phrase}).Matches(tt.input); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsk(t *testing.T) {
+	tests := []struct {
+		name     string
+		prompt   Prompt
+		input    string
+		want     bool
+		wantText string
+	}{
+		{"confirmed", Prompt{}, "yes\n", true, "Type 'yes' to proceed: "},
+		{"declined", Prompt{}, "no\n", false, "Type 'yes' to proceed: "},
+		{"end of input", Prompt{}, "", false, "Type 'yes' to proceed: "},
+		{"custom phrase", Prompt{Phrase: "12345"}, "12345\n", true, "Type '12345' to proceed: "},
+		{"custom text", Prompt{Text: "Type the installation ID", Phrase: "12345"}, "12345\n", true, "Type the installation ID: "},
+		{"answered within the timeout", Prompt{Timeout: time.Minute}, "yes\n", true, "Type 'yes' to proceed: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if got := tt.prompt.Ask(strings.NewReader(tt.input), &out); got != tt.want {
+				t.Errorf("Ask() = %v, want %v", got, tt.want)
+			}
+			if out.String() != "\n"+tt.wantText {
+				t.Errorf("printed %q, want %q", out.String(), "\n"+tt.wantText)
+			}
+		})
+	}
+}
+
+func TestAskSkip(t *testing.T) {
+	in := strings.NewReader("no\n")
+	var out bytes.Buffer
+	if !(Prompt{Skip: true}).Ask(in, &out) {
+		t.Error("Ask() declined a skipped prompt")
+	}
+	if out.Len() != 0 {
+		t.Errorf("a skipped prompt printed %q", out.String())
+	}
+	if in.Len() != 3 {
+		t.Error("a skipped prompt read the input")
+	}
+}
+
+func TestAskTimeout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	var out bytes.Buffer
+	if (Prompt{Timeout: 50 * time.Millisecond}).Ask(r, &out) {
+		t.Error("Ask() confirmed although nothing was typed")
+	}
+	if !strings.Contains(out.String(), "Nothing was typed within 50ms") {
+		t.Errorf("printed %q, want the timeout", out.String())
+	}
+
+	// The timed out read was cancelled, so it doesn't take the answer to the next prompt
+	w.WriteString("yes\n")
+	if !(Prompt{Timeout: time.Minute}).Ask(r, &out) {
+		t.Error("the next prompt didn't get the answer typed for it")
+	}
+}
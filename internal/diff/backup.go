@@ -0,0 +1,68 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+// ReadBackup reads a saved snapshot of old entities: a JSON array of entities as the Port API
+// returns them, or an object with them under "entities" like a search response. Every entity
+// needs an identifier, the rest of the structure is compared as is.
+func ReadBackup(path string) ([]port.Entity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var entities []port.Entity
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapped struct {
+			Entities []port.Entity `json:"entities"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("invalid backup %s: %w", path, err)
+		}
+		entities = wrapped.Entities
+	} else if err := json.Unmarshal(data, &entities); err != nil {
+		return nil, fmt.Errorf("invalid backup %s: %w", path, err)
+	}
+
+	for i, e := range entities {
+		if e.Identifier == "" {
+			return nil, fmt.Errorf("invalid backup %s: entity %d has no identifier", path, i+1)
+		}
+	}
+	return entities, nil
+}
+
+// BackupBlueprint returns the blueprint of the backup's entities, or fallback when they don't
+// have a single one
+func BackupBlueprint(entities []port.Entity, fallback string) string {
+	blueprint := ""
+	for _, e := range entities {
+		if e.Blueprint == "" || (blueprint != "" && e.Blueprint != blueprint) {
+			return fallback
+		}
+		blueprint = e.Blueprint
+	}
+	if blueprint == "" {
+		return fallback
+	}
+	return blueprint
+}
+
+// CompareBackup compares a backup of the old entities with the target blueprint's current new
+// entities, for when the old ones were already migrated and a live search finds none
+func (s *Service) CompareBackup(backup []port.Entity, sourceBP, targetBP, oldInstallID, newInstallID string) (*models.DiffResult, error) {
+	targetEntities, err := s.targetClient.SearchNewEntitiesByBlueprint(targetBP, newInstallID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target entities: %w", err)
+	}
+
+	return s.compareEntities(sourceBP, targetBP, oldInstallID, backup, targetEntities), nil
+}
@@ -0,0 +1,86 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+// changeColumns maps the columns of the changes table to their values
+var changeColumns = map[string]func(s *Service, change *models.EntityChange) string{
+	"identifier": func(s *Service, change *models.EntityChange) string {
+		return change.Identifier
+	},
+	"type": func(s *Service, change *models.EntityChange) string {
+		return change.Type
+	},
+	"kinds": func(s *Service, change *models.EntityChange) string {
+		return strings.Join(change.Kinds, ",")
+	},
+	"changedProps": func(s *Service, change *models.EntityChange) string {
+		if change.Type != "changed" {
+			return ""
+		}
+		return fmt.Sprintf("%d", len(flattenDiffs(s.PropertyDiffs(change), s.ignore)))
+	},
+	"title": func(s *Service, change *models.EntityChange) string {
+		return change.Title
+	},
+	"oldDatasource": func(s *Service, change *models.EntityChange) string {
+		if change.Source == nil {
+			return ""
+		}
+		return change.Source.Datasource
+	},
+	"newDatasource": func(s *Service, change *models.EntityChange) string {
+		if change.Target == nil {
+			return ""
+		}
+		return change.Target.Datasource
+	},
+}
+
+// ChangeColumns returns the names of the columns available for PrintChangesTable
+func ChangeColumns() []string {
+	names := make([]string, 0, len(changeColumns))
+	for name := range changeColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PrintChangesTable prints the non-identical entities as a table with the given columns
+func (s *Service) PrintChangesTable(changes []models.EntityChange, columns []string) {
+	if len(changes) == 0 {
+		return
+	}
+
+	sorted := make([]*models.EntityChange, len(changes))
+	for i := range changes {
+		sorted[i] = &changes[i]
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Identifier < sorted[j].Identifier
+	})
+
+	w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, change := range sorted {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = changeColumns[column](s, change)
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	w.Flush()
+	fmt.Fprintln(s.out)
+}
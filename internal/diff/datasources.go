@@ -0,0 +1,107 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/omby8888/port-github-migrator/internal/jsonout"
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+// DatasourceExport is the result of comparing just which identifiers are on the old and the new
+// datasource of a blueprint, without comparing their properties
+type DatasourceExport struct {
+	SourceBlueprint string   `json:"sourceBlueprint"`
+	TargetBlueprint string   `json:"targetBlueprint"`
+	Migrated        []string `json:"migrated"`             // on both datasources
+	StillOld        []string `json:"stillOld"`             // only on the old datasource, not migrated
+	OnlyNew         []string `json:"onlyNew"`              // only on the new datasource, orphaned
+	Collisions      []string `json:"collisions,omitempty"` // identifiers normalized to the same key, matched exactly instead
+}
+
+// CompareDatasources fetches only the identifiers and datasources of the old and new entities and
+// sorts them into migrated, still old and only new, far cheaper than CompareBlueprints
+func (s *Service) CompareDatasources(sourceBP, targetBP, oldInstallID, newInstallID string) (DatasourceExport, error) {
+	export := DatasourceExport{
+		SourceBlueprint: sourceBP,
+		TargetBlueprint: targetBP,
+		Migrated:        []string{},
+		StillOld:        []string{},
+		OnlyNew:         []string{},
+	}
+
+	sourceEntities, targetEntities, err := searchBoth(
+		func() ([]port.Entity, error) {
+			return s.client.SearchOldIdentifiers(sourceBP, oldInstallID)
+		},
+		func() ([]port.Entity, error) {
+			return s.targetClient.SearchNewIdentifiers(targetBP, newInstallID)
+		},
+	)
+	if err != nil {
+		return export, err
+	}
+	if s.strict {
+		sourceEntities, _ = port.SplitByDatasource(sourceEntities, port.OldDatasource(oldInstallID))
+	}
+
+	// As in CompareBlueprints, identifiers whose normalized keys collide are matched exactly
+	var collided map[string]bool
+	if s.normalizeID != nil {
+		collided, export.Collisions = s.identifierCollisions(sourceEntities, targetEntities)
+	}
+
+	targetKeys := make(map[string]bool, len(targetEntities))
+	for _, e := range targetEntities {
+		targetKeys[s.collisionKey(e.Identifier, collided)] = true
+	}
+	sourceKeys := make(map[string]bool, len(sourceEntities))
+	for _, e := range sourceEntities {
+		key := s.collisionKey(e.Identifier, collided)
+		sourceKeys[key] = true
+		if targetKeys[key] {
+			export.Migrated = append(export.Migrated, e.Identifier)
+		} else {
+			export.StillOld = append(export.StillOld, e.Identifier)
+		}
+	}
+	for _, e := range targetEntities {
+		if !sourceKeys[s.collisionKey(e.Identifier, collided)] {
+			export.OnlyNew = append(export.OnlyNew, e.Identifier)
+		}
+	}
+
+	sort.Strings(export.Migrated)
+	sort.Strings(export.StillOld)
+	sort.Strings(export.OnlyNew)
+	return export, nil
+}
+
+// PrintDatasourceComparison prints the counts of the three sets, listing the still old and
+// only new identifiers that need attention
+func (s *Service) PrintDatasourceComparison(export DatasourceExport) {
+	fmt.Fprintln(s.out)
+	fmt.Fprintf(s.out, "📊 %s (old) → %s (new)\n", export.SourceBlueprint, export.TargetBlueprint)
+	fmt.Fprintln(s.out, "   "+repeatString("─", 40))
+	fmt.Fprintf(s.out, "   ✅ %d migrated\n", len(export.Migrated))
+	fmt.Fprintf(s.out, "   ⚠️  %d still old\n", len(export.StillOld))
+	for _, id := range export.StillOld {
+		fmt.Fprintf(s.out, "       • %s\n", id)
+	}
+	fmt.Fprintf(s.out, "   ❌ %d only new\n", len(export.OnlyNew))
+	for _, id := range export.OnlyNew {
+		fmt.Fprintf(s.out, "       • %s\n", id)
+	}
+	if len(export.Collisions) > 0 {
+		fmt.Fprintf(s.out, "   ⚠️  %d identifier collisions after normalizing, matched exactly instead\n", len(export.Collisions))
+		for _, collision := range export.Collisions {
+			fmt.Fprintf(s.out, "       • %s\n", collision)
+		}
+	}
+}
+
+// WriteDatasourceJSON writes the datasource comparisons as a JSON array
+func (s *Service) WriteDatasourceJSON(exports []DatasourceExport) error {
+	encoder := jsonout.NewEncoder(s.out, s.jsonCompact)
+	return encoder.Encode(exports)
+}
@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"sort"
+
+	"github.com/omby8888/port-github-migrator/internal/jsonout"
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+// ResultExport is the JSON representation of a blueprint comparison
+type ResultExport struct {
+	SourceBlueprint   string             `json:"sourceBlueprint"`
+	TargetBlueprint   string             `json:"targetBlueprint"`
+	Summary           models.DiffSummary `json:"summary"`
+	Changes           []ChangeExport     `json:"changes"`
+	LooseMatches      []string           `json:"looseMatches,omitempty"`
+	NormalizedMatches []string           `json:"normalizedMatches,omitempty"`
+	Collisions        []string           `json:"collisions,omitempty"`
+	Insignificant     []string           `json:"insignificant,omitempty"`
+}
+
+// ChangeExport is the JSON representation of a single entity difference
+type ChangeExport struct {
+	Identifier string          `json:"identifier"`
+	Title      string          `json:"title,omitempty"` // with --show-titles
+	Type       string          `json:"type"`
+	Kinds      []string        `json:"kinds,omitempty"`
+	Diffs      []FlattenedDiff `json:"diffs,omitempty"`
+}
+
+// Export converts a comparison result to its JSON representation,
+// keeping only changed entities when changedOnly is set
+func (s *Service) Export(result *models.DiffResult, changedOnly bool) ResultExport {
+	export := ResultExport{
+		SourceBlueprint:   result.SourceBlueprint,
+		TargetBlueprint:   result.TargetBlueprint,
+		Summary:           result.Summary,
+		Changes:           []ChangeExport{},
+		LooseMatches:      result.LooseMatches,
+		NormalizedMatches: result.NormalizedMatches,
+		Collisions:        result.Collisions,
+		Insignificant:     result.Insignificant,
+	}
+
+	for i := range result.Changes {
+		change := &result.Changes[i]
+		if changedOnly && change.Type != "changed" {
+			continue
+		}
+
+		changeExport := ChangeExport{
+			Identifier: change.Identifier,
+			Type:       change.Type,
+			Kinds:      change.Kinds,
+		}
+		if s.showTitles {
+			changeExport.Title = change.Title
+		}
+		if change.Type == "changed" {
+			changeExport.Diffs = flattenDiffs(s.PropertyDiffs(change), s.ignore)
+			sort.Slice(changeExport.Diffs, func(i, j int) bool {
+				return changeExport.Diffs[i].Path < changeExport.Diffs[j].Path
+			})
+		}
+		export.Changes = append(export.Changes, changeExport)
+	}
+
+	sort.Slice(export.Changes, func(i, j int) bool {
+		return export.Changes[i].Identifier < export.Changes[j].Identifier
+	})
+
+	return export
+}
+
+// WriteJSON writes the exported comparison results as a JSON array
+func (s *Service) WriteJSON(exports []ResultExport) error {
+	encoder := jsonout.NewEncoder(s.out, s.jsonCompact)
+	return encoder.Encode(exports)
+}
@@ -0,0 +1,84 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/omby8888/port-github-migrator/internal/jsonout"
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+// GapExport explains the difference between the old and new entity counts of a blueprint
+type GapExport struct {
+	SourceBlueprint string      `json:"sourceBlueprint"`
+	TargetBlueprint string      `json:"targetBlueprint"`
+	OldCount        int         `json:"oldCount"`
+	NewCount        int         `json:"newCount"`
+	OnlyInOld       []GapEntity `json:"onlyInOld"` // not migrated
+	OnlyInNew       []GapEntity `json:"onlyInNew"` // orphaned
+}
+
+// GapEntity is an entity present on one side only
+type GapEntity struct {
+	Identifier string `json:"identifier"`
+	Title      string `json:"title,omitempty"`
+}
+
+// ExplainGap lists the entities behind the count gap of a comparison, sorted by identifier
+func ExplainGap(result *models.DiffResult) GapExport {
+	summary := result.Summary
+	gap := GapExport{
+		SourceBlueprint: result.SourceBlueprint,
+		TargetBlueprint: result.TargetBlueprint,
+		OldCount:        summary.Identical + summary.Changed + summary.NotMigrated,
+		NewCount:        summary.Identical + summary.Changed + summary.Orphaned,
+		OnlyInOld:       []GapEntity{},
+		OnlyInNew:       []GapEntity{},
+	}
+
+	for _, change := range result.Changes {
+		switch change.Type {
+		case "notMigrated":
+			entity := GapEntity{Identifier: change.Identifier}
+			if change.Source != nil {
+				entity.Title = change.Source.Title
+			}
+			gap.OnlyInOld = append(gap.OnlyInOld, entity)
+		case "orphaned":
+			entity := GapEntity{Identifier: change.Identifier}
+			if change.Target != nil {
+				entity.Title = change.Target.Title
+			}
+			gap.OnlyInNew = append(gap.OnlyInNew, entity)
+		}
+	}
+
+	for _, entities := range [][]GapEntity{gap.OnlyInOld, gap.OnlyInNew} {
+		sort.Slice(entities, func(i, j int) bool {
+			return entities[i].Identifier < entities[j].Identifier
+		})
+	}
+	return gap
+}
+
+// PrintGap prints the entities behind the count gap, an identifier and its title per line
+// so the identifiers can be copied into an --entities-file
+func (s *Service) PrintGap(gap GapExport) {
+	fmt.Fprintln(s.out)
+	fmt.Fprintf(s.out, "🔍 %s (old): %d, %s (new): %d, gap of %d\n", gap.SourceBlueprint, gap.OldCount, gap.TargetBlueprint, gap.NewCount, gap.OldCount-gap.NewCount)
+
+	fmt.Fprintf(s.out, "\n# %d only in old (not migrated)\n", len(gap.OnlyInOld))
+	for _, entity := range gap.OnlyInOld {
+		fmt.Fprintf(s.out, "%s\t%s\n", entity.Identifier, entity.Title)
+	}
+	fmt.Fprintf(s.out, "\n# %d only in new (orphaned)\n", len(gap.OnlyInNew))
+	for _, entity := range gap.OnlyInNew {
+		fmt.Fprintf(s.out, "%s\t%s\n", entity.Identifier, entity.Title)
+	}
+}
+
+// WriteGapJSON writes the explained gaps as a JSON array
+func (s *Service) WriteGapJSON(gaps []GapExport) error {
+	encoder := jsonout.NewEncoder(s.out, s.jsonCompact)
+	return encoder.Encode(gaps)
+}
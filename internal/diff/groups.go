@@ -0,0 +1,92 @@
+package diff
+
+import (
+	"fmt"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+// Statuses a compared blueprint is grouped by, from its dominant condition
+const (
+	StatusFullyMigrated = "fullyMigrated"
+	StatusNotMigrated   = "notMigrated"
+	StatusOrphaned      = "orphaned"
+	StatusChanged       = "changed"
+)
+
+// statusGroups lists the status groups in the order they are printed, most pressing first
+var statusGroups = []struct {
+	status string
+	title  string
+}{
+	{StatusNotMigrated, "⚠️  Blueprints mostly not migrated"},
+	{StatusOrphaned, "❌ Blueprints with mostly orphans"},
+	{StatusChanged, "📝 Blueprints with mostly changed entities"},
+	{StatusFullyMigrated, "✅ Blueprints fully migrated"},
+}
+
+// BlueprintStatus returns the dominant condition of a compared blueprint: the kind of difference
+// with the most entities, ties going to not migrated, then orphaned, then changed
+func BlueprintStatus(result *models.DiffResult) string {
+	summary := result.Summary
+	switch {
+	case summary.NotMigrated == 0 && summary.Orphaned == 0 && summary.Changed == 0:
+		return StatusFullyMigrated
+	case summary.NotMigrated >= summary.Orphaned && summary.NotMigrated >= summary.Changed:
+		return StatusNotMigrated
+	case summary.Orphaned >= summary.Changed:
+		return StatusOrphaned
+	}
+	return StatusChanged
+}
+
+// HasDifferences reports whether a compared blueprint has any not migrated, changed or orphaned entities
+func HasDifferences(result *models.DiffResult) bool {
+	return BlueprintStatus(result) != StatusFullyMigrated
+}
+
+// PrintCleanCount prints the totals of every compared blueprint, including the ones left out
+// by --only-with-diffs, and how many are clean
+func (s *Service) PrintCleanCount(results []*models.DiffResult) {
+	var total models.DiffSummary
+	clean := 0
+	for _, result := range results {
+		total.Identical += result.Summary.Identical
+		total.NotMigrated += result.Summary.NotMigrated
+		total.Changed += result.Summary.Changed
+		total.Orphaned += result.Summary.Orphaned
+		if !HasDifferences(result) {
+			clean++
+		}
+	}
+
+	fmt.Fprintf(s.out, "📊 Total of %d blueprints: %d identical, %d not migrated, %d changed, %d orphaned\n",
+		len(results), total.Identical, total.NotMigrated, total.Changed, total.Orphaned)
+	fmt.Fprintf(s.out, "✅ %d blueprints clean, %d with differences\n", clean, len(results)-clean)
+}
+
+// PrintGroupedSummary prints the compared blueprints bucketed by their dominant condition,
+// a triage view of which blueprints need attention
+func (s *Service) PrintGroupedSummary(results []*models.DiffResult) {
+	groups := make(map[string][]*models.DiffResult)
+	for _, result := range results {
+		status := BlueprintStatus(result)
+		groups[status] = append(groups[status], result)
+	}
+
+	fmt.Fprintln(s.out)
+	for _, group := range statusGroups {
+		members := groups[group.status]
+		if len(members) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(s.out, "%s (%d)\n", group.title, len(members))
+		for _, result := range members {
+			summary := result.Summary
+			fmt.Fprintf(s.out, "       • %s → %s: %d identical, %d not migrated, %d changed, %d orphaned\n",
+				result.SourceBlueprint, result.TargetBlueprint, summary.Identical, summary.NotMigrated, summary.Changed, summary.Orphaned)
+		}
+		fmt.Fprintln(s.out)
+	}
+}
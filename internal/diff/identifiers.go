@@ -0,0 +1,95 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+// identifierTransforms are the built-in normalizations applied to both source and target
+// identifiers before matching them, for integrations that format identifiers differently
+var identifierTransforms = map[string]func(string) string{
+	"lowercase": strings.ToLower,
+	"slash-to-underscore": func(id string) string {
+		return strings.ReplaceAll(id, "/", "_")
+	},
+	"dash-to-underscore": func(id string) string {
+		return strings.ReplaceAll(id, "-", "_")
+	},
+	"strip-org": func(id string) string {
+		// org/repo → repo
+		return id[strings.LastIndex(id, "/")+1:]
+	},
+}
+
+// IdentifierTransforms returns the names of the built-in identifier transforms
+func IdentifierTransforms() []string {
+	names := make([]string, 0, len(identifierTransforms))
+	for name := range identifierTransforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newIdentifierNormalizer chains the named transforms in order, nil when there are none
+func newIdentifierNormalizer(names []string) (func(string) string, error) {
+	var transforms []func(string) string
+	for _, name := range names {
+		transform, ok := identifierTransforms[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid identifier transform %q, expected one of %s", name, strings.Join(IdentifierTransforms(), ", "))
+		}
+		transforms = append(transforms, transform)
+	}
+
+	if len(transforms) == 0 {
+		return nil, nil
+	}
+	return func(id string) string {
+		for _, transform := range transforms {
+			id = transform(id)
+		}
+		return id
+	}, nil
+}
+
+// identifierCollisions finds the keys that identifiers of the same side normalize to more than once,
+// e.g. MyRepo and myrepo with --ignore-case. Entities with such a key can't be told apart by it, so
+// they are matched on their exact identifier instead. Each collision is described for the summary.
+func (s *Service) identifierCollisions(sourceEntities, targetEntities []port.Entity) (map[string]bool, []string) {
+	collided := make(map[string]bool)
+	var collisions []string
+	for _, side := range []struct {
+		name     string
+		entities []port.Entity
+	}{{"old", sourceEntities}, {"new", targetEntities}} {
+		byKey := make(map[string][]string)
+		for _, e := range side.entities {
+			key := s.matchKey(e.Identifier)
+			byKey[key] = append(byKey[key], e.Identifier)
+		}
+		for key, ids := range byKey {
+			if len(ids) < 2 {
+				continue
+			}
+			collided[key] = true
+			sort.Strings(ids)
+			collisions = append(collisions, fmt.Sprintf("%s → %s (%s)", strings.Join(ids, ", "), key, side.name))
+		}
+	}
+	sort.Strings(collisions)
+	return collided, collisions
+}
+
+// collisionKey returns the key an identifier is matched on, its exact identifier when its
+// normalized key collides. Exact keys start with a NUL so they can't equal a normalized one.
+func (s *Service) collisionKey(identifier string, collided map[string]bool) string {
+	key := s.matchKey(identifier)
+	if collided[key] {
+		return "\x00" + identifier
+	}
+	return key
+}
@@ -0,0 +1,63 @@
+package diff
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// propertyMatcher matches flattened property paths against glob patterns
+type propertyMatcher []string
+
+// newPropertyMatcher validates the glob patterns and returns a matcher
+func newPropertyMatcher(patterns []string) (propertyMatcher, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid property pattern %q: %w", pattern, err)
+		}
+	}
+	return propertyMatcher(patterns), nil
+}
+
+// matches reports whether the flattened path matches any pattern
+func (p propertyMatcher) matches(flatPath string) bool {
+	for _, pattern := range p {
+		if ok, _ := path.Match(pattern, flatPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesOrParent reports whether the flattened path or one of its parents matches any pattern,
+// so "properties.config" covers "properties.config.timeout"
+func (p propertyMatcher) matchesOrParent(flatPath string) bool {
+	for {
+		if p.matches(flatPath) {
+			return true
+		}
+		i := strings.LastIndex(flatPath, ".")
+		if i < 0 {
+			return false
+		}
+		flatPath = flatPath[:i]
+	}
+}
+
+// strip returns a copy of value without the nested keys whose path matches a pattern
+func (p propertyMatcher) strip(prefix string, value interface{}) interface{} {
+	m, ok := value.(map[string]interface{})
+	if !ok || len(p) == 0 {
+		return value
+	}
+
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		childPath := prefix + "." + k
+		if p.matches(childPath) {
+			continue
+		}
+		result[k] = p.strip(childPath, v)
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/omby8888/port-github-migrator/internal/jsonout"
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+// RelationExport lists the entities of a blueprint whose named relation differs between old and new
+type RelationExport struct {
+	SourceBlueprint string          `json:"sourceBlueprint"`
+	TargetBlueprint string          `json:"targetBlueprint"`
+	Relation        string          `json:"relation"`
+	Differences     []RelationValue `json:"differences"`
+}
+
+// RelationValue is an entity's value of the relation on each side, nil when it isn't set
+type RelationValue struct {
+	Identifier  string      `json:"identifier"`
+	Old         interface{} `json:"old"`
+	New         interface{} `json:"new"`
+	NotMigrated bool        `json:"notMigrated,omitempty"` // the entity itself is missing on the new side
+}
+
+// RelationDiffs scopes a comparison to one relation: changed entities whose relation differs,
+// compared in the --relations-compare-mode, and not migrated entities that had it set
+func (s *Service) RelationDiffs(result *models.DiffResult, relation string) RelationExport {
+	export := RelationExport{
+		SourceBlueprint: result.SourceBlueprint,
+		TargetBlueprint: result.TargetBlueprint,
+		Relation:        relation,
+		Differences:     []RelationValue{},
+	}
+
+	for _, change := range result.Changes {
+		switch change.Type {
+		case "changed":
+			oldValue := relationValue(s.filterRelations(change.Source.Relations), relation)
+			newValue := relationValue(s.filterRelations(change.Target.Relations), relation)
+			if !reflect.DeepEqual(oldValue, newValue) {
+				export.Differences = append(export.Differences, RelationValue{Identifier: change.Identifier, Old: oldValue, New: newValue})
+			}
+		case "notMigrated":
+			if oldValue := relationValue(s.filterRelations(change.Source.Relations), relation); oldValue != nil {
+				export.Differences = append(export.Differences, RelationValue{Identifier: change.Identifier, Old: oldValue, NotMigrated: true})
+			}
+		}
+	}
+
+	sort.Slice(export.Differences, func(i, j int) bool {
+		return export.Differences[i].Identifier < export.Differences[j].Identifier
+	})
+	return export
+}
+
+// relationValue returns the target of a named relation, nil when it isn't set
+func relationValue(relations interface{}, name string) interface{} {
+	m, ok := relations.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	value := m[name]
+	if targets, isArray := value.([]interface{}); isArray && len(targets) == 0 {
+		return nil
+	}
+	return value
+}
+
+// PrintRelationDiffs prints the entities whose relation differs with its old and new value
+func (s *Service) PrintRelationDiffs(export RelationExport) {
+	fmt.Fprintln(s.out)
+	fmt.Fprintf(s.out, "🔗 %s (old) → %s (new): %d entities with a different %s relation\n",
+		export.SourceBlueprint, export.TargetBlueprint, len(export.Differences), export.Relation)
+	for _, diff := range export.Differences {
+		if diff.NotMigrated {
+			fmt.Fprintf(s.out, "  • %s: %v → (not migrated)\n", diff.Identifier, diff.Old)
+			continue
+		}
+		fmt.Fprintf(s.out, "  • %s: %v → %v\n", diff.Identifier, formatRelation(diff.Old), formatRelation(diff.New))
+	}
+}
+
+// formatRelation prints an unset relation as (missing) rather than <nil>
+func formatRelation(value interface{}) interface{} {
+	if value == nil {
+		return "(missing)"
+	}
+	return value
+}
+
+// WriteRelationJSON writes the relation differences as a JSON array
+func (s *Service) WriteRelationJSON(exports []RelationExport) error {
+	encoder := jsonout.NewEncoder(s.out, s.jsonCompact)
+	return encoder.Encode(exports)
+}
@@ -2,47 +2,262 @@ package diff
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/notify"
 	"github.com/omby8888/port-github-migrator/internal/port"
 )
 
 // Service handles entity comparison
 type Service struct {
-	client *port.Client
+	client            *port.Client // searches the old entities
+	targetClient      *port.Client // searches the new entities, may be another Port organization
+	ignore            propertyMatcher
+	significant       propertyMatcher // nil means every difference is significant
+	nullEqualsMissing bool
+	excludedProps     map[string]bool
+	identifiers       []string
+	relationsMode     string
+	changeKinds       map[string]bool // selected kinds of difference, nil means all
+	includeMeta       bool
+	strict            bool
+	normalizeID       func(string) string // identifier transforms applied before matching, nil matches exactly
+	jsonCompact       bool
+	showTitles        bool
+	events            notify.EventSink
+	out               io.Writer
 }
 
-// NewService creates a new diff service
-func NewService(client *port.Client) *Service {
-	return &Service{client: client}
+// Kinds of difference between two entities
+const (
+	ChangeKindProperty = "property"
+	ChangeKindRelation = "relation"
+	ChangeKindTitle    = "title"
+	ChangeKindMeta     = "meta"
+)
+
+// Relations compare modes
+const (
+	RelationsNormalized  = "normalized"   // ignore array order and unwrap single-element arrays
+	RelationsExact       = "exact"        // compare relations as returned by Port
+	RelationsIgnoreOrder = "ignore-order" // treat relation arrays as sets
+	RelationsKeysOnly    = "keys-only"    // only compare which relations are set, not their targets
+)
+
+// metaFields are the Port provenance fields, only compared with DiffOptions.IncludeMeta
+var metaFields = []string{"createdAt", "updatedAt", "createdBy", "updatedBy"}
+
+// NewService creates a new diff service. The target client searches the new entities,
+// nil uses the source client for both sides.
+func NewService(client, targetClient *port.Client, options models.DiffOptions) (*Service, error) {
+	ignore, err := newPropertyMatcher(options.IgnoreProperties)
+	if err != nil {
+		return nil, err
+	}
+
+	significant, err := newPropertyMatcher(options.SignificantProps)
+	if err != nil {
+		return nil, err
+	}
+
+	transforms := options.IDTransforms
+	if options.IgnoreCase {
+		transforms = append([]string{"lowercase"}, transforms...)
+	}
+	normalizeID, err := newIdentifierNormalizer(transforms)
+	if err != nil {
+		return nil, err
+	}
+
+	relationsMode := options.RelationsCompareMode
+	switch relationsMode {
+	case "":
+		relationsMode = RelationsNormalized
+	case RelationsNormalized, RelationsExact, RelationsIgnoreOrder, RelationsKeysOnly:
+	default:
+		return nil, fmt.Errorf("invalid relations compare mode %q, expected normalized, exact, ignore-order or keys-only", relationsMode)
+	}
+
+	var changeKinds map[string]bool
+	if len(options.ChangeKinds) > 0 {
+		changeKinds = make(map[string]bool)
+		for _, kind := range options.ChangeKinds {
+			switch kind {
+			case ChangeKindProperty, ChangeKindRelation, ChangeKindTitle, ChangeKindMeta:
+				changeKinds[kind] = true
+			default:
+				return nil, fmt.Errorf("invalid change kind %q, expected property, relation, title or meta", kind)
+			}
+		}
+	}
+
+	excludedProps := map[string]bool{"blueprint": true}
+	if !options.IncludeMeta {
+		for _, field := range metaFields {
+			excludedProps[field] = true
+		}
+	}
+
+	if targetClient == nil {
+		targetClient = client
+	}
+
+	return &Service{
+		client:            client,
+		targetClient:      targetClient,
+		ignore:            ignore,
+		significant:       significant,
+		nullEqualsMissing: options.NullEqualsMissing,
+		identifiers:       options.Identifiers,
+		relationsMode:     relationsMode,
+		changeKinds:       changeKinds,
+		includeMeta:       options.IncludeMeta,
+		strict:            options.Strict,
+		normalizeID:       normalizeID,
+		jsonCompact:       options.JSONCompact,
+		showTitles:        options.ShowTitles,
+		out:               os.Stdout,
+		excludedProps:     excludedProps,
+	}, nil
 }
 
 // CompareBlueprints compares entities between source and target blueprints
 func (s *Service) CompareBlueprints(sourceBP, targetBP, oldInstallID, newInstallID string) (*models.DiffResult, error) {
-	// Get source entities (old installation)
-	sourceEntities, err := s.client.SearchOldEntitiesByBlueprint(sourceBP, oldInstallID)
+	sourceEntities, targetEntities, err := searchBoth(
+		func() ([]port.Entity, error) {
+			return s.client.SearchOldEntitiesByBlueprint(sourceBP, oldInstallID)
+		},
+		func() ([]port.Entity, error) {
+			return s.targetClient.SearchNewEntitiesByBlueprint(targetBP, newInstallID)
+		},
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get source entities: %w", err)
+		return nil, err
 	}
 
-	// Get target entities (new installation)
-	targetEntities, err := s.client.SearchNewEntitiesByBlueprint(targetBP, newInstallID)
+	return s.compareEntities(sourceBP, targetBP, oldInstallID, sourceEntities, targetEntities), nil
+}
+
+// searchBoth runs the independent old and new searches concurrently, reporting both errors when
+// both fail
+func searchBoth(searchSource, searchTarget func() ([]port.Entity, error)) ([]port.Entity, []port.Entity, error) {
+	var sourceEntities, targetEntities []port.Entity
+	var sourceErr, targetErr error
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sourceEntities, sourceErr = searchSource()
+	}()
+	targetEntities, targetErr = searchTarget()
+	wg.Wait()
+
+	var errs []error
+	if sourceErr != nil {
+		errs = append(errs, fmt.Errorf("failed to get source entities: %w", sourceErr))
+	}
+	if targetErr != nil {
+		errs = append(errs, fmt.Errorf("failed to get target entities: %w", targetErr))
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	return sourceEntities, targetEntities, nil
+}
+
+// CompareEntity compares a single entity between the source and target blueprints,
+// searching only for that identifier. The result has at most one change.
+func (s *Service) CompareEntity(sourceBP, targetBP, identifier, oldInstallID, newInstallID string) (*models.DiffResult, error) {
+	var sourceEntities, targetEntities []port.Entity
+
+	source, err := s.client.SearchOldEntity(sourceBP, oldInstallID, identifier)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get target entities: %w", err)
+		return nil, fmt.Errorf("failed to get source entity: %w", err)
+	}
+	if source != nil {
+		sourceEntities = append(sourceEntities, *source)
+	}
+
+	target, err := s.targetClient.SearchNewEntity(targetBP, newInstallID, identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target entity: %w", err)
+	}
+	if target != nil {
+		targetEntities = append(targetEntities, *target)
 	}
 
-	// Index entities
+	result := s.compareEntities(sourceBP, targetBP, oldInstallID, sourceEntities, targetEntities)
+	if len(result.Changes) == 0 && result.Summary.Identical == 0 && len(result.LooseMatches) == 0 {
+		result.NotFound = append(result.NotFound, identifier)
+		if err := s.findOtherDatasource(result, identifier); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// findOtherDatasource records the datasource of an entity that neither datasource search found,
+// when it exists on the source or target blueprint at all
+func (s *Service) findOtherDatasource(result *models.DiffResult, identifier string) error {
+	lookups := []struct {
+		client    *port.Client
+		blueprint string
+	}{{s.client, result.SourceBlueprint}, {s.targetClient, result.TargetBlueprint}}
+	if s.targetClient == s.client && result.TargetBlueprint == result.SourceBlueprint {
+		lookups = lookups[:1]
+	}
+
+	for _, lookup := range lookups {
+		entity, err := lookup.client.GetEntity(lookup.blueprint, identifier)
+		if port.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get entity: %w", err)
+		}
+		result.OtherDatasources = map[string]string{identifier: entity.Datasource}
+		return nil
+	}
+	return nil
+}
+
+// compareEntities compares the fetched source and target entities
+func (s *Service) compareEntities(sourceBP, targetBP, oldInstallID string, sourceEntities, targetEntities []port.Entity) *models.DiffResult {
+	// The contains search can match unrelated datasources, strict mode only keeps the exact old one
+	var looseMatches []string
+	if s.strict {
+		var loose []port.Entity
+		sourceEntities, loose = port.SplitByDatasource(sourceEntities, port.OldDatasource(oldInstallID))
+		for _, e := range loose {
+			looseMatches = append(looseMatches, e.Identifier)
+		}
+		sort.Strings(looseMatches)
+	}
+
+	// Index entities, identifiers whose normalized keys collide are matched exactly
+	var collided map[string]bool
+	var collisions []string
+	if s.normalizeID != nil {
+		collided, collisions = s.identifierCollisions(sourceEntities, targetEntities)
+	}
 	sourceMap := make(map[string]port.Entity)
 	targetMap := make(map[string]port.Entity)
 
 	for _, e := range sourceEntities {
-		sourceMap[e.Identifier] = e
+		sourceMap[s.collisionKey(e.Identifier, collided)] = e
 	}
 
 	for _, e := range targetEntities {
-		targetMap[e.Identifier] = e
+		targetMap[s.collisionKey(e.Identifier, collided)] = e
 	}
 
 	// Compare entities
@@ -50,172 +265,573 @@ func (s *Service) CompareBlueprints(sourceBP, targetBP, oldInstallID, newInstall
 		SourceBlueprint: sourceBP,
 		TargetBlueprint: targetBP,
 		Changes:         []models.EntityChange{},
+		LooseMatches:    looseMatches,
+		Collisions:      collisions,
 	}
 
-	excludedProps := map[string]bool{
-		"blueprint": true,
-		"createdAt": true,
-		"updatedAt": true,
-		"createdBy": true,
-		"updatedBy": true,
+	// Restrict the comparison to the requested identifiers
+	if len(s.identifiers) > 0 {
+		wanted := make(map[string]bool)
+		for _, id := range s.identifiers {
+			wanted[s.matchKey(id)] = true
+		}
+		// Colliding entities are keyed exactly, so they're selected by their normalized key
+		found := make(map[string]bool)
+		for key, e := range sourceMap {
+			if normalized := s.matchKey(e.Identifier); wanted[normalized] {
+				found[normalized] = true
+			} else {
+				delete(sourceMap, key)
+			}
+		}
+		for key, e := range targetMap {
+			if normalized := s.matchKey(e.Identifier); wanted[normalized] {
+				found[normalized] = true
+			} else {
+				delete(targetMap, key)
+			}
+		}
+		reported := make(map[string]bool)
+		for _, id := range s.identifiers {
+			if key := s.matchKey(id); !found[key] && !reported[key] {
+				reported[key] = true
+				result.NotFound = append(result.NotFound, id)
+			}
+		}
 	}
 
 	// Check common entities
-	for id, sourceEntity := range sourceMap {
-		if targetEntity, exists := targetMap[id]; exists {
+	for key, sourceEntity := range sourceMap {
+		id := sourceEntity.Identifier
+		if targetEntity, exists := targetMap[key]; exists {
 			// Entity exists in both
-			if entitiesEqual(sourceEntity, targetEntity, excludedProps) {
+			if targetEntity.Identifier != id {
+				result.NormalizedMatches = append(result.NormalizedMatches, fmt.Sprintf("%s → %s", id, targetEntity.Identifier))
+			}
+			kinds := s.differingKinds(sourceEntity, targetEntity)
+			if len(kinds) > 0 && !s.differsSignificantly(sourceEntity, targetEntity) {
+				// Shown in the summary, but doesn't count as a change
+				result.Summary.Identical++
+				result.Insignificant = append(result.Insignificant, id)
+			} else if len(kinds) == 0 {
 				result.Summary.Identical++
 			} else {
 				result.Summary.Changed++
+				// Property diffs are computed on demand, see PropertyDiffs
+				source, target := sourceEntity, targetEntity
 				change := models.EntityChange{
 					Identifier: id,
+					Title:      sourceEntity.Title,
 					Type:       "changed",
-					PropertyDiffs: getPropertyDiffs(sourceEntity, targetEntity, excludedProps),
+					Kinds:      kinds,
+					Source:     &source,
+					Target:     &target,
 				}
 				result.Changes = append(result.Changes, change)
 			}
 		} else {
 			// Entity only in source (not migrated)
 			result.Summary.NotMigrated++
+			source := sourceEntity
 			change := models.EntityChange{
 				Identifier: id,
+				Title:      sourceEntity.Title,
 				Type:       "notMigrated",
 				OldEntity:  entityToMap(sourceEntity),
+				Source:     &source,
 			}
 			result.Changes = append(result.Changes, change)
 		}
 	}
 
 	// Check for orphaned entities (only in target)
-	for id := range targetMap {
-		if _, exists := sourceMap[id]; !exists {
+	for key, targetEntity := range targetMap {
+		if _, exists := sourceMap[key]; !exists {
 			result.Summary.Orphaned++
+			target := targetEntity
 			change := models.EntityChange{
-				Identifier: id,
+				Identifier: targetEntity.Identifier,
+				Title:      targetEntity.Title,
 				Type:       "orphaned",
+				Target:     &target,
 			}
 			result.Changes = append(result.Changes, change)
 		}
 	}
 
-	return result, nil
+	sort.Strings(result.NormalizedMatches)
+	sort.Strings(result.Insignificant)
+
+	notify.Emit(s.events, notify.NewEvent(notify.EventBlueprintCompared, sourceBP, map[string]interface{}{
+		"targetBlueprint": targetBP,
+		"summary":         result.Summary,
+	}))
+
+	return result
+}
+
+// differsSignificantly reports whether two differing entities differ in a --significant-property path,
+// always true when none are set
+func (s *Service) differsSignificantly(e1, e2 port.Entity) bool {
+	if len(s.significant) == 0 {
+		return true
+	}
+	for _, d := range flattenDiffs(s.getPropertyDiffs(e1, e2), s.ignore) {
+		if s.significant.matchesOrParent(d.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKey returns the key source and target identifiers are matched on
+func (s *Service) matchKey(identifier string) string {
+	if s.normalizeID != nil {
+		return s.normalizeID(identifier)
+	}
+	return identifier
+}
+
+// SetEventSink sets where the comparison events are sent, nil sends none
+func (s *Service) SetEventSink(sink notify.EventSink) {
+	s.events = sink
+}
+
+// SetOutput overrides where the diff results are written
+func (s *Service) SetOutput(out io.Writer) {
+	s.out = out
+}
+
+// DiagnoseDatasources groups all target entities by datasource to explain entities missed by the new datasource search
+func (s *Service) DiagnoseDatasources(result *models.DiffResult) (*models.DatasourceDiagnosis, error) {
+	entities, err := s.targetClient.SearchAllEntitiesByBlueprint(result.TargetBlueprint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target entities: %w", err)
+	}
+
+	diagnosis := &models.DatasourceDiagnosis{
+		Blueprint:   result.TargetBlueprint,
+		Datasources: make(map[string]int),
+		Mismatched:  make(map[string]string),
+	}
+
+	datasources := make(map[string]string)
+	for _, e := range entities {
+		datasource := e.Datasource
+		if datasource == "" {
+			datasource = "(none)"
+		}
+		diagnosis.Datasources[datasource]++
+		datasources[e.Identifier] = datasource
+	}
+
+	// Not migrated entities that exist on the target under another datasource
+	for _, change := range result.Changes {
+		if change.Type != "notMigrated" {
+			continue
+		}
+		if datasource, exists := datasources[change.Identifier]; exists {
+			diagnosis.Mismatched[change.Identifier] = datasource
+		}
+	}
+
+	return diagnosis, nil
+}
+
+// PrintDiagnosis prints the datasources found on the target blueprint
+func (s *Service) PrintDiagnosis(diagnosis *models.DatasourceDiagnosis) {
+	fmt.Fprintf(s.out, "🔍 Datasources on %s (all entities)\n", diagnosis.Blueprint)
+	fmt.Fprintln(s.out, "   " + repeatString("─", 40))
+
+	datasources := make([]string, 0, len(diagnosis.Datasources))
+	for ds := range diagnosis.Datasources {
+		datasources = append(datasources, ds)
+	}
+	sort.Strings(datasources)
+	for _, ds := range datasources {
+		fmt.Fprintf(s.out, "   %6d  %s\n", diagnosis.Datasources[ds], ds)
+	}
+
+	if len(diagnosis.Mismatched) > 0 {
+		fmt.Fprintf(s.out, "   ⚠️  %d not migrated entities exist on the target with another datasource\n", len(diagnosis.Mismatched))
+		identifiers := make([]string, 0, len(diagnosis.Mismatched))
+		for id := range diagnosis.Mismatched {
+			identifiers = append(identifiers, id)
+		}
+		sort.Strings(identifiers)
+		for _, id := range identifiers {
+			fmt.Fprintf(s.out, "       • %s (%s)\n", id, diagnosis.Mismatched[id])
+		}
+	}
+	fmt.Fprintln(s.out)
+}
+
+// PropertyDiffs returns the property diffs of a changed entity, computing them on first use
+func (s *Service) PropertyDiffs(change *models.EntityChange) map[string]models.PropertyDiff {
+	if change.PropertyDiffs == nil && change.Source != nil && change.Target != nil {
+		change.PropertyDiffs = s.getPropertyDiffs(*change.Source, *change.Target)
+	}
+	return change.PropertyDiffs
 }
 
 // PrintSummary prints the diff summary with entity identifiers
 func (s *Service) PrintSummary(result *models.DiffResult) {
-	fmt.Println()
-	fmt.Printf("📊 %s (old) → %s (new)\n", result.SourceBlueprint, result.TargetBlueprint)
-	fmt.Println("   " + repeatString("─", 40))
-	fmt.Printf("   ✅ %d identical\n", result.Summary.Identical)
+	fmt.Fprintln(s.out)
+	fmt.Fprintf(s.out, "📊 %s (old) → %s (new)\n", result.SourceBlueprint, result.TargetBlueprint)
+	fmt.Fprintln(s.out, "   " + repeatString("─", 40))
+	fmt.Fprintf(s.out, "   ✅ %d identical\n", result.Summary.Identical)
 	if result.Summary.NotMigrated > 0 {
-		fmt.Printf("   ⚠️  %d not migrated (only in old)\n", result.Summary.NotMigrated)
+		fmt.Fprintf(s.out, "   ⚠️  %d not migrated (only in old)\n", result.Summary.NotMigrated)
 		for _, change := range result.Changes {
 			if change.Type == "notMigrated" {
-				fmt.Printf("       • %s\n", change.Identifier)
+				fmt.Fprintf(s.out, "       • %s\n", s.entityLabel(&change))
 			}
 		}
 	}
-	fmt.Printf("   📝 %d changed\n", result.Summary.Changed)
+	fmt.Fprintf(s.out, "   📝 %d changed\n", result.Summary.Changed)
 	if result.Summary.Orphaned > 0 {
-		fmt.Printf("   ❌ %d orphaned (only in new)\n", result.Summary.Orphaned)
+		fmt.Fprintf(s.out, "   ❌ %d orphaned (only in new)\n", result.Summary.Orphaned)
 		for _, change := range result.Changes {
 			if change.Type == "orphaned" {
-				fmt.Printf("       • %s\n", change.Identifier)
+				fmt.Fprintf(s.out, "       • %s\n", s.entityLabel(&change))
+			}
+		}
+	}
+	if len(result.Insignificant) > 0 {
+		fmt.Fprintf(s.out, "   ➖ %d identical apart from non-significant properties\n", len(result.Insignificant))
+		for _, id := range result.Insignificant {
+			fmt.Fprintf(s.out, "       • %s\n", id)
+		}
+	}
+	if len(result.NormalizedMatches) > 0 {
+		fmt.Fprintf(s.out, "   🔀 %d matched only after normalizing identifiers\n", len(result.NormalizedMatches))
+		for _, match := range result.NormalizedMatches {
+			fmt.Fprintf(s.out, "       • %s\n", match)
+		}
+	}
+	if len(result.Collisions) > 0 {
+		fmt.Fprintf(s.out, "   ⚠️  %d identifier collisions after normalizing, matched exactly instead\n", len(result.Collisions))
+		for _, collision := range result.Collisions {
+			fmt.Fprintf(s.out, "       • %s\n", collision)
+		}
+	}
+	if len(result.LooseMatches) > 0 {
+		fmt.Fprintf(s.out, "   🚫 %d skipped by --strict (datasource only contains the old one)\n", len(result.LooseMatches))
+		for _, id := range result.LooseMatches {
+			fmt.Fprintf(s.out, "       • %s\n", id)
+		}
+	}
+	if len(result.NotFound) > 0 {
+		fmt.Fprintf(s.out, "   ❓ %d requested identifiers not found on either side\n", len(result.NotFound))
+		for _, id := range result.NotFound {
+			fmt.Fprintf(s.out, "       • %s\n", id)
+		}
+	}
+	fmt.Fprintln(s.out)
+}
+
+// PrintEntityDiff prints the outcome of CompareEntity with every flattened difference
+func (s *Service) PrintEntityDiff(result *models.DiffResult, identifier string) {
+	fmt.Fprintln(s.out)
+	fmt.Fprintf(s.out, "📊 %s: %s (old) → %s (new)\n", identifier, result.SourceBlueprint, result.TargetBlueprint)
+	fmt.Fprintln(s.out, "   " + repeatString("─", 40))
+
+	switch {
+	case len(result.NotFound) > 0:
+		fmt.Fprintln(s.out, "   ❓ not found with either datasource")
+		if datasource, exists := result.OtherDatasources[identifier]; exists {
+			if datasource == "" {
+				datasource = "unknown"
+			}
+			fmt.Fprintf(s.out, "      exists with another datasource: %s\n", datasource)
+		}
+	case len(result.LooseMatches) > 0:
+		fmt.Fprintln(s.out, "   🚫 skipped by --strict (datasource only contains the old one)")
+	case result.Summary.Identical > 0:
+		fmt.Fprintln(s.out, "   ✅ identical")
+	}
+
+	for i := range result.Changes {
+		change := &result.Changes[i]
+		switch change.Type {
+		case "notMigrated":
+			fmt.Fprintln(s.out, "   ⚠️  not migrated (only in old)")
+		case "orphaned":
+			fmt.Fprintln(s.out, "   ❌ orphaned (only in new)")
+		case "changed":
+			fmt.Fprintf(s.out, "   📝 changed (%s)\n", strings.Join(change.Kinds, ", "))
+			flatDiffs := flattenDiffs(s.PropertyDiffs(change), s.ignore)
+			sort.Slice(flatDiffs, func(i, j int) bool {
+				return flatDiffs[i].Path < flatDiffs[j].Path
+			})
+			for _, path := range flatDiffs {
+				fmt.Fprintf(s.out, "    - %s: %v\n", path.Path, path.OldValue)
+				fmt.Fprintf(s.out, "    + %s: %v\n", path.Path, path.NewValue)
 			}
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(s.out)
 }
 
-// PrintDetailedDiffs prints detailed property diffs for changed entities
-func (s *Service) PrintDetailedDiffs(changes []models.EntityChange, limit int) {
-	// Count changed entities
-	changedCount := 0
-	for _, change := range changes {
-		if change.Type == "changed" {
-			changedCount++
+// PrintDetailedDiffs prints detailed property diffs for a page of the changed entities,
+// sorted by identifier so offset and limit page through them. Compact prints a single
+// line per entity with just the changed paths.
+func (s *Service) PrintDetailedDiffs(changes []models.EntityChange, offset, limit int, compact bool) {
+	var changed []*models.EntityChange
+	for i := range changes {
+		if changes[i].Type == "changed" {
+			changed = append(changed, &changes[i])
 		}
 	}
 
-	if changedCount == 0 {
+	if len(changed) == 0 || limit < 1 {
+		return
+	}
+	if offset >= len(changed) {
+		fmt.Fprintf(s.out, "⏭️  --offset %d is past the %d changed entities\n\n", offset, len(changed))
 		return
 	}
 
-	fmt.Println("📋 Changed Entities (showing first " + fmt.Sprintf("%d", limit) + "):")
-	fmt.Println()
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].Identifier < changed[j].Identifier
+	})
 
-	shown := 0
-	for _, change := range changes {
-		if change.Type != "changed" {
-			continue
-		}
+	if offset < 0 {
+		offset = 0
+	}
+	end := len(changed)
+	if limit < end-offset {
+		end = offset + limit
+	}
 
-		if shown >= limit {
-			fmt.Printf("⏭️  Showing %d of %d changed entities. Use --limit to show more.\n", limit, changedCount)
-			break
-		}
+	fmt.Fprintf(s.out, "📋 Changed Entities (showing %d-%d of %d):\n", offset+1, end, len(changed))
+	fmt.Fprintln(s.out)
 
-		if shown > 0 {
-			fmt.Println()
+	for i, change := range changed[offset:end] {
+		if compact {
+			s.printCompactDiff(change)
+			continue
+		}
+		if i > 0 {
+			fmt.Fprintln(s.out)
 		}
 
-		fmt.Printf("  • %s\n", change.Identifier)
+		fmt.Fprintf(s.out, "  • %s\n", s.entityLabel(change))
 		// Flatten nested diffs into dot-notation paths
-		flatDiffs := flattenDiffs(change.PropertyDiffs)
+		flatDiffs := flattenDiffs(s.PropertyDiffs(change), s.ignore)
 		for _, path := range flatDiffs {
-			fmt.Printf("    - %s: %v\n", path.Path, path.OldValue)
-			fmt.Printf("    + %s: %v\n", path.Path, path.NewValue)
+			fmt.Fprintf(s.out, "    - %s: %v\n", path.Path, path.OldValue)
+			fmt.Fprintf(s.out, "    + %s: %v\n", path.Path, path.NewValue)
 		}
-		shown++
 	}
 
-	fmt.Println()
+	if end < len(changed) {
+		fmt.Fprintf(s.out, "\n⏭️  Showing %d-%d of %d changed entities. Use --offset %d to show the next page.\n", offset+1, end, len(changed), end)
+	}
+	fmt.Fprintln(s.out)
+}
+
+// printCompactDiff prints a changed entity on one line: identifier (N props changed): path1, path2
+func (s *Service) printCompactDiff(change *models.EntityChange) {
+	flatDiffs := flattenDiffs(s.PropertyDiffs(change), s.ignore)
+	paths := make([]string, len(flatDiffs))
+	for i, path := range flatDiffs {
+		paths[i] = path.Path
+	}
+	sort.Strings(paths)
+
+	fmt.Fprintf(s.out, "  • %s (%d props changed): %s\n", s.entityLabel(change), len(paths), strings.Join(paths, ", "))
+}
+
+// entityLabel returns how a listed entity is shown: its identifier, followed by its title with --show-titles
+func (s *Service) entityLabel(change *models.EntityChange) string {
+	if !s.showTitles || change.Title == "" {
+		return change.Identifier
+	}
+	return fmt.Sprintf("%s (%s)", change.Identifier, change.Title)
 }
 
 // Helper functions
 
-func entitiesEqual(e1, e2 port.Entity, excluded map[string]bool) bool {
+// kindSelected reports whether differences of the given kind count towards a change
+func (s *Service) kindSelected(kind string) bool {
+	return s.changeKinds == nil || s.changeKinds[kind]
+}
+
+// differingKinds returns the selected kinds of difference between two entities, empty when they are equal
+func (s *Service) differingKinds(e1, e2 port.Entity) []string {
+	var kinds []string
+
 	// Compare title
-	if e1.Title != e2.Title {
-		return false
+	if s.kindSelected(ChangeKindTitle) && e1.Title != e2.Title && !s.ignore.matches("title") {
+		kinds = append(kinds, ChangeKindTitle)
 	}
 
 	// Compare properties (excluding specific fields)
-	m1 := filterProperties(e1.Properties, excluded)
-	m2 := filterProperties(e2.Properties, excluded)
-
-	if !reflect.DeepEqual(m1, m2) {
-		return false
+	if s.kindSelected(ChangeKindProperty) && !reflect.DeepEqual(s.filterProperties(e1.Properties), s.filterProperties(e2.Properties)) {
+		kinds = append(kinds, ChangeKindProperty)
 	}
 
 	// Compare relations
-	return reflect.DeepEqual(e1.Relations, e2.Relations)
+	if s.kindSelected(ChangeKindRelation) && !reflect.DeepEqual(s.filterRelations(e1.Relations), s.filterRelations(e2.Relations)) {
+		kinds = append(kinds, ChangeKindRelation)
+	}
+
+	// Compare provenance fields
+	if s.kindSelected(ChangeKindMeta) && len(s.metaDiffs(e1, e2)) > 0 {
+		kinds = append(kinds, ChangeKindMeta)
+	}
+
+	return kinds
 }
 
-func filterProperties(props map[string]interface{}, excluded map[string]bool) map[string]interface{} {
+// metaDiffs returns the differing provenance fields, empty unless meta fields are included
+func (s *Service) metaDiffs(e1, e2 port.Entity) map[string]models.PropertyDiff {
+	diffs := make(map[string]models.PropertyDiff)
+	if !s.includeMeta {
+		return diffs
+	}
+
+	values := func(e port.Entity) map[string]string {
+		return map[string]string{
+			"createdAt": e.CreatedAt,
+			"updatedAt": e.UpdatedAt,
+			"createdBy": e.CreatedBy,
+			"updatedBy": e.UpdatedBy,
+		}
+	}
+	v1, v2 := values(e1), values(e2)
+	for _, field := range metaFields {
+		if v1[field] != v2[field] && !s.ignore.matches(field) {
+			diffs[field] = models.PropertyDiff{
+				OldValue: v1[field],
+				NewValue: v2[field],
+			}
+		}
+	}
+	return diffs
+}
+
+func (s *Service) filterProperties(props map[string]interface{}) map[string]interface{} {
 	result := make(map[string]interface{})
 	for k, v := range props {
-		if !excluded[k] {
-			result[k] = v
+		if s.excludedProps[k] || s.ignore.matches("properties."+k) {
+			continue
+		}
+		v = s.ignore.strip("properties."+k, v)
+		if s.nullEqualsMissing {
+			// A null property is treated as if it was never set
+			if v == nil {
+				continue
+			}
+			v = dropNulls(v)
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// dropNulls removes null values from nested objects so they compare equal to missing keys
+func dropNulls(value interface{}) interface{} {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if v == nil {
+			continue
+		}
+		result[k] = dropNulls(v)
+	}
+	return result
+}
+
+func (s *Service) filterRelations(relations interface{}) interface{} {
+	if s.ignore.matches("relations") {
+		return nil
+	}
+	// Port omits the relations of some entities, which is the same as having none, so
+	// missing relations don't make an entity with empty ones changed
+	if relations == nil {
+		relations = map[string]interface{}{}
+	}
+	relations = s.ignore.strip("relations", relations)
+	switch s.relationsMode {
+	case RelationsNormalized:
+		relations = normalizeRelations(relations, true)
+	case RelationsIgnoreOrder:
+		relations = normalizeRelations(relations, false)
+	case RelationsKeysOnly:
+		relations = relationKeys(relations)
+	}
+	return relations
+}
+
+// normalizeRelations sorts many-relation arrays, and unwraps single-element arrays when
+// unwrap is set, so that semantically identical relations compare equal
+func normalizeRelations(relations interface{}, unwrap bool) interface{} {
+	m, ok := relations.(map[string]interface{})
+	if !ok {
+		return relations
+	}
+
+	result := make(map[string]interface{}, len(m))
+	for name, target := range m {
+		targets, isArray := target.([]interface{})
+		if !isArray {
+			result[name] = target
+			continue
+		}
+		if unwrap && len(targets) == 1 {
+			result[name] = targets[0]
+			continue
+		}
+
+		sorted := make([]interface{}, len(targets))
+		copy(sorted, targets)
+		sort.Slice(sorted, func(i, j int) bool {
+			return fmt.Sprint(sorted[i]) < fmt.Sprint(sorted[j])
+		})
+		result[name] = sorted
+	}
+	return result
+}
+
+// relationKeys reduces relations to whether each of them is set
+func relationKeys(relations interface{}) interface{} {
+	m, ok := relations.(map[string]interface{})
+	if !ok {
+		return relations
+	}
+
+	result := make(map[string]interface{}, len(m))
+	for name, target := range m {
+		if targets, isArray := target.([]interface{}); target == nil || (isArray && len(targets) == 0) {
+			continue
 		}
+		result[name] = true
 	}
 	return result
 }
 
-func getPropertyDiffs(e1, e2 port.Entity, excluded map[string]bool) map[string]models.PropertyDiff {
+func (s *Service) getPropertyDiffs(e1, e2 port.Entity) map[string]models.PropertyDiff {
 	diffs := make(map[string]models.PropertyDiff)
 
 	// Check title
-	if e1.Title != e2.Title {
+	if s.kindSelected(ChangeKindTitle) && e1.Title != e2.Title && !s.ignore.matches("title") {
 		diffs["title"] = models.PropertyDiff{
 			OldValue: e1.Title,
 			NewValue: e2.Title,
 		}
 	}
 
-	m1 := filterProperties(e1.Properties, excluded)
-	m2 := filterProperties(e2.Properties, excluded)
+	m1 := s.filterProperties(e1.Properties)
+	m2 := s.filterProperties(e2.Properties)
+	if !s.kindSelected(ChangeKindProperty) {
+		m1, m2 = nil, nil
+	}
 
 	// Check e1 properties
 	for k, v1 := range m1 {
@@ -239,10 +855,19 @@ func getPropertyDiffs(e1, e2 port.Entity, excluded map[string]bool) map[string]m
 	}
 
 	// Check relations
-	if !reflect.DeepEqual(e1.Relations, e2.Relations) {
+	r1 := s.filterRelations(e1.Relations)
+	r2 := s.filterRelations(e2.Relations)
+	if s.kindSelected(ChangeKindRelation) && !reflect.DeepEqual(r1, r2) {
 		diffs["relations"] = models.PropertyDiff{
-			OldValue: e1.Relations,
-			NewValue: e2.Relations,
+			OldValue: r1,
+			NewValue: r2,
+		}
+	}
+
+	// Check provenance fields
+	if s.kindSelected(ChangeKindMeta) {
+		for field, diff := range s.metaDiffs(e1, e2) {
+			diffs[field] = diff
 		}
 	}
 
@@ -266,18 +891,21 @@ func repeatString(s string, count int) string {
 
 // FlattenedDiff represents a single flattened property difference
 type FlattenedDiff struct {
-	Path     string
-	OldValue interface{}
-	NewValue interface{}
+	Path     string      `json:"path"`
+	OldValue interface{} `json:"oldValue"`
+	NewValue interface{} `json:"newValue"`
 }
 
-// flattenDiffs flattens nested property diffs into dot-notation paths
-func flattenDiffs(diffs map[string]models.PropertyDiff) []FlattenedDiff {
+// flattenDiffs flattens nested property diffs into dot-notation paths, skipping ignored paths
+func flattenDiffs(diffs map[string]models.PropertyDiff, ignore propertyMatcher) []FlattenedDiff {
 	var result []FlattenedDiff
 
 	for prop, diff := range diffs {
-		flattenedPaths := flattenValue(prop, diff.OldValue, diff.NewValue)
-		result = append(result, flattenedPaths...)
+		for _, flattened := range flattenValue(prop, diff.OldValue, diff.NewValue) {
+			if !ignore.matches(flattened.Path) {
+				result = append(result, flattened)
+			}
+		}
 	}
 
 	return result
@@ -0,0 +1,356 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/port"
+	"github.com/omby8888/port-github-migrator/internal/port/porttest"
+)
+
+// newTestService returns a service printing into a buffer
+func newTestService(t *testing.T, options models.DiffOptions) (*Service, *bytes.Buffer) {
+	t.Helper()
+	s, err := NewService(nil, nil, options)
+	if err != nil {
+		t.Fatalf("NewService() failed: %v", err)
+	}
+	var out bytes.Buffer
+	s.SetOutput(&out)
+	return s, &out
+}
+
+func changedEntities(identifiers ...string) []models.EntityChange {
+	changes := make([]models.EntityChange, len(identifiers))
+	for i, id := range identifiers {
+		changes[i] = models.EntityChange{
+			Identifier:    id,
+			Type:          "changed",
+			PropertyDiffs: map[string]models.PropertyDiff{"language": {OldValue: "Go", NewValue: "Rust"}},
+		}
+	}
+	return changes
+}
+
+func TestPrintDetailedDiffsPaging(t *testing.T) {
+	tests := []struct {
+		name      string
+		offset    int
+		limit     int
+		want      string // header, empty when nothing is listed
+		wantShown []string
+	}{
+		{"first page", 0, 2, "showing 1-2 of 3", []string{"a", "b"}},
+		{"last page", 2, 2, "showing 3-3 of 3", []string{"c"}},
+		{"limit past the end", 1, 100, "showing 2-3 of 3", []string{"b", "c"}},
+		{"huge limit", 0, int(^uint(0) >> 1), "showing 1-3 of 3", []string{"a", "b", "c"}},
+		{"zero limit", 0, 0, "", nil},
+		{"negative limit", 0, -1, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, out := newTestService(t, models.DiffOptions{})
+			s.PrintDetailedDiffs(changedEntities("c", "a", "b"), tt.offset, tt.limit, true)
+
+			if tt.want == "" {
+				if out.Len() != 0 {
+					t.Errorf("expected no output, got:\n%s", out)
+				}
+				return
+			}
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output doesn't contain %q:\n%s", tt.want, out)
+			}
+			for _, id := range []string{"a", "b", "c"} {
+				shown := strings.Contains(out.String(), "• "+id+" ")
+				if want := contains(tt.wantShown, id); shown != want {
+					t.Errorf("%s shown = %v, want %v:\n%s", id, shown, want, out)
+				}
+			}
+		})
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCompareSparseEntities(t *testing.T) {
+	populated := map[string]interface{}{"language": "Go"}
+	relations := map[string]interface{}{"team": "platform"}
+	tests := []struct {
+		name                     string
+		sourceProps, targetProps map[string]interface{}
+		sourceRels, targetRels   interface{}
+		wantDiffs                []string // flattened paths that differ, none when identical
+	}{
+		{"nil properties on both sides", nil, nil, nil, nil, nil},
+		{"nil vs empty properties", nil, map[string]interface{}{}, nil, nil, nil},
+		{"nil vs populated properties", nil, populated, nil, nil, []string{"properties.language"}},
+		{"populated vs nil properties", populated, nil, nil, nil, []string{"properties.language"}},
+		{"nil relations on both sides", populated, populated, nil, nil, nil},
+		{"nil vs empty relations", populated, populated, nil, map[string]interface{}{}, nil},
+		{"nil vs populated relations", populated, populated, nil, relations, []string{"relations.team"}},
+		{"populated vs nil relations", populated, populated, relations, nil, []string{"relations.team"}},
+	}
+	modes := []string{RelationsNormalized, RelationsExact, RelationsIgnoreOrder, RelationsKeysOnly}
+	for _, tt := range tests {
+		for _, mode := range modes {
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				s, _ := newTestService(t, models.DiffOptions{RelationsCompareMode: mode})
+				source := port.Entity{Identifier: "repo", Properties: tt.sourceProps, Relations: tt.sourceRels}
+				target := port.Entity{Identifier: "repo", Properties: tt.targetProps, Relations: tt.targetRels}
+				result := s.compareEntities("githubRepository", "githubRepository", "12345", []port.Entity{source}, []port.Entity{target})
+
+				if tt.wantDiffs == nil {
+					if result.Summary.Identical != 1 || result.Summary.Changed != 0 {
+						t.Fatalf("expected the entity to be identical, got summary %+v", result.Summary)
+					}
+					return
+				}
+				if result.Summary.Changed != 1 {
+					t.Fatalf("expected the entity to be changed, got summary %+v", result.Summary)
+				}
+				var paths []string
+				for _, d := range flattenDiffs(s.PropertyDiffs(&result.Changes[0]), s.ignore) {
+					paths = append(paths, d.Path)
+				}
+				if strings.Join(paths, ",") != strings.Join(tt.wantDiffs, ",") {
+					t.Errorf("differing paths = %v, want %v", paths, tt.wantDiffs)
+				}
+			})
+		}
+	}
+}
+
+func TestCompareEntity(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	srv.AddEntities("githubRepository",
+		port.Entity{Identifier: "stale", Datasource: port.OldDatasource("12345")},
+		port.Entity{Identifier: "migrated", Datasource: client.NewDatasource("1.0.0", "67890")},
+		port.Entity{Identifier: "manual", Datasource: "port-api"},
+	)
+
+	tests := []struct {
+		identifier    string
+		want          string
+		wantNotFound  bool
+		wantElsewhere string
+	}{
+		{"stale", "not migrated (only in old)", false, ""},
+		{"migrated", "orphaned (only in new)", false, ""},
+		{"manual", "exists with another datasource: port-api", true, "port-api"},
+		{"missing", "not found with either datasource", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.identifier, func(t *testing.T) {
+			s, err := NewService(client, nil, models.DiffOptions{})
+			if err != nil {
+				t.Fatalf("NewService() failed: %v", err)
+			}
+			var out bytes.Buffer
+			s.SetOutput(&out)
+
+			result, err := s.CompareEntity("githubRepository", "githubRepository", tt.identifier, "12345", "67890")
+			if err != nil {
+				t.Fatalf("CompareEntity() failed: %v", err)
+			}
+			if notFound := len(result.NotFound) > 0; notFound != tt.wantNotFound {
+				t.Errorf("not found = %v, want %v", notFound, tt.wantNotFound)
+			}
+			if got := result.OtherDatasources[tt.identifier]; got != tt.wantElsewhere {
+				t.Errorf("other datasource = %q, want %q", got, tt.wantElsewhere)
+			}
+
+			s.PrintEntityDiff(result, tt.identifier)
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output doesn't contain %q:\n%s", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestCompareIgnoreCaseCollisions(t *testing.T) {
+	source := []port.Entity{{Identifier: "MyRepo"}, {Identifier: "myrepo"}, {Identifier: "Other"}}
+	target := []port.Entity{{Identifier: "myrepo"}, {Identifier: "other"}}
+
+	tests := []struct {
+		name        string
+		identifiers []string
+		wantTypes   map[string]string // identifier -> change type, identical ones are left out
+		wantSummary models.DiffSummary
+	}{
+		{"all", nil, map[string]string{"MyRepo": "notMigrated"}, models.DiffSummary{Identical: 2, NotMigrated: 1}},
+		// The requested identifier selects every entity its normalized key collides on
+		{"requested", []string{"MYREPO"}, map[string]string{"MyRepo": "notMigrated"}, models.DiffSummary{Identical: 1, NotMigrated: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, out := newTestService(t, models.DiffOptions{IgnoreCase: true, Identifiers: tt.identifiers})
+			result := s.compareEntities("githubRepository", "githubRepository", "12345", source, target)
+
+			if result.Summary != tt.wantSummary {
+				t.Errorf("summary = %+v, want %+v", result.Summary, tt.wantSummary)
+			}
+			types := make(map[string]string)
+			for _, change := range result.Changes {
+				types[change.Identifier] = change.Type
+			}
+			if fmt.Sprint(types) != fmt.Sprint(tt.wantTypes) {
+				t.Errorf("changes = %v, want %v", types, tt.wantTypes)
+			}
+			if len(result.NotFound) > 0 {
+				t.Errorf("not found = %v, want none", result.NotFound)
+			}
+			if want := []string{"MyRepo, myrepo → myrepo (old)"}; fmt.Sprint(result.Collisions) != fmt.Sprint(want) {
+				t.Errorf("collisions = %v, want %v", result.Collisions, want)
+			}
+
+			s.PrintSummary(result)
+			if !strings.Contains(out.String(), "1 identifier collisions after normalizing") {
+				t.Errorf("summary doesn't report the collision:\n%s", out)
+			}
+			if export := s.Export(result, false); len(export.Collisions) != 1 {
+				t.Errorf("export collisions = %v, want 1", export.Collisions)
+			}
+		})
+	}
+}
+
+func TestCompareIDTransformCollisions(t *testing.T) {
+	tests := []struct {
+		name           string
+		transforms     []string
+		source, target []string
+		wantTypes      map[string]string // identifier -> change type, identical ones are left out
+		wantCollisions []string
+	}{
+		{
+			"old side", []string{"strip-org"},
+			[]string{"org-a/api", "org-b/api", "org-a/web"}, []string{"api", "web"},
+			map[string]string{"org-a/api": "notMigrated", "org-b/api": "notMigrated", "api": "orphaned"},
+			[]string{"org-a/api, org-b/api → api (old)"},
+		},
+		{
+			"new side", []string{"slash-to-underscore"},
+			[]string{"org/repo"}, []string{"org/repo", "org_repo"},
+			map[string]string{"org_repo": "orphaned"},
+			[]string{"org/repo, org_repo → org_repo (new)"},
+		},
+		{
+			"chained transforms", []string{"lowercase", "dash-to-underscore"},
+			[]string{"My-Repo", "my_repo"}, []string{"my_repo"},
+			map[string]string{"My-Repo": "notMigrated"},
+			[]string{"My-Repo, my_repo → my_repo (old)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var source, target []port.Entity
+			for _, id := range tt.source {
+				source = append(source, port.Entity{Identifier: id})
+			}
+			for _, id := range tt.target {
+				target = append(target, port.Entity{Identifier: id})
+			}
+
+			s, _ := newTestService(t, models.DiffOptions{IDTransforms: tt.transforms})
+			result := s.compareEntities("githubRepository", "githubRepository", "12345", source, target)
+
+			types := make(map[string]string)
+			for _, change := range result.Changes {
+				types[change.Identifier] = change.Type
+			}
+			if fmt.Sprint(types) != fmt.Sprint(tt.wantTypes) {
+				t.Errorf("changes = %v, want %v", types, tt.wantTypes)
+			}
+			if fmt.Sprint(result.Collisions) != fmt.Sprint(tt.wantCollisions) {
+				t.Errorf("collisions = %v, want %v", result.Collisions, tt.wantCollisions)
+			}
+		})
+	}
+}
+
+func TestCompareDatasourcesCollisions(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	newDatasource := client.NewDatasource("1.0.0", "67890")
+	srv.AddEntities("githubRepository",
+		port.Entity{Identifier: "org-a/api", Datasource: port.OldDatasource("12345")},
+		port.Entity{Identifier: "org-b/api", Datasource: port.OldDatasource("12345")},
+		port.Entity{Identifier: "org-a/web", Datasource: port.OldDatasource("12345")},
+		port.Entity{Identifier: "api", Datasource: newDatasource},
+		port.Entity{Identifier: "web", Datasource: newDatasource},
+	)
+
+	s, err := NewService(client, nil, models.DiffOptions{IDTransforms: []string{"strip-org"}})
+	if err != nil {
+		t.Fatalf("NewService() failed: %v", err)
+	}
+	var out bytes.Buffer
+	s.SetOutput(&out)
+
+	export, err := s.CompareDatasources("githubRepository", "githubRepository", "12345", "67890")
+	if err != nil {
+		t.Fatalf("CompareDatasources() failed: %v", err)
+	}
+	// A single new api can't account for both old ones
+	want := DatasourceExport{
+		SourceBlueprint: "githubRepository",
+		TargetBlueprint: "githubRepository",
+		Migrated:        []string{"org-a/web"},
+		StillOld:        []string{"org-a/api", "org-b/api"},
+		OnlyNew:         []string{"api"},
+		Collisions:      []string{"org-a/api, org-b/api → api (old)"},
+	}
+	if fmt.Sprintf("%+v", export) != fmt.Sprintf("%+v", want) {
+		t.Errorf("got %+v, want %+v", export, want)
+	}
+
+	s.PrintDatasourceComparison(export)
+	if !strings.Contains(out.String(), "org-a/api, org-b/api → api (old)") {
+		t.Errorf("output doesn't report the collision:\n%s", out.String())
+	}
+}
+
+func TestRelationsCompareModes(t *testing.T) {
+	old := map[string]interface{}{"team": []interface{}{"platform"}, "repos": []interface{}{"a", "b"}}
+	tests := []struct {
+		name      string
+		relations map[string]interface{}
+		want      map[string]bool // mode -> changed, "" is the default
+	}{
+		{"unwrapped single element", map[string]interface{}{"team": "platform", "repos": []interface{}{"a", "b"}},
+			map[string]bool{"": false, RelationsNormalized: false, RelationsExact: true, RelationsIgnoreOrder: true, RelationsKeysOnly: false}},
+		{"reordered", map[string]interface{}{"team": []interface{}{"platform"}, "repos": []interface{}{"b", "a"}},
+			map[string]bool{"": false, RelationsNormalized: false, RelationsExact: true, RelationsIgnoreOrder: false, RelationsKeysOnly: false}},
+		{"other target", map[string]interface{}{"team": []interface{}{"security"}, "repos": []interface{}{"a", "b"}},
+			map[string]bool{"": true, RelationsNormalized: true, RelationsExact: true, RelationsIgnoreOrder: true, RelationsKeysOnly: false}},
+		{"relation removed", map[string]interface{}{"repos": []interface{}{"a", "b"}},
+			map[string]bool{"": true, RelationsNormalized: true, RelationsExact: true, RelationsIgnoreOrder: true, RelationsKeysOnly: true}},
+	}
+	for _, tt := range tests {
+		for mode, want := range tt.want {
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				s, _ := newTestService(t, models.DiffOptions{RelationsCompareMode: mode})
+				source := port.Entity{Identifier: "repo", Relations: old}
+				target := port.Entity{Identifier: "repo", Relations: tt.relations}
+				result := s.compareEntities("githubRepository", "githubRepository", "12345", []port.Entity{source}, []port.Entity{target})
+				if changed := result.Summary.Changed == 1; changed != want {
+					t.Errorf("changed = %v, want %v", changed, want)
+				}
+			})
+		}
+	}
+}
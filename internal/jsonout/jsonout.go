@@ -0,0 +1,16 @@
+// Package jsonout writes the --output json results, indented for reading or compact for piping
+package jsonout
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// NewEncoder returns an encoder writing to w, indented by two spaces unless compact
+func NewEncoder(w io.Writer, compact bool) *json.Encoder {
+	encoder := json.NewEncoder(w)
+	if !compact {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder
+}
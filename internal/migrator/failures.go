@@ -0,0 +1,74 @@
+package migrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+// errorFile is the machine-readable report of a migration's failures, re-run with --from-error-file
+type errorFile struct {
+	GeneratedAt time.Time            `json:"generatedAt"`
+	Failures    []models.FailedBatch `json:"failures"`
+}
+
+// WriteErrorFile writes the failures of a migration to path
+func WriteErrorFile(path string, failures []models.FailedBatch) error {
+	report := errorFile{
+		GeneratedAt: time.Now().UTC(),
+		Failures:    failures,
+	}
+
+	data, _ := json.MarshalIndent(report, "", "  ")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write error file: %w", err)
+	}
+	return nil
+}
+
+// readErrorFile reads the failures of an error file as plan entries, a failure
+// without identifiers becomes an entry for all of its blueprint's old entities
+func readErrorFile(path string) ([]models.PlanEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read error file: %w", err)
+	}
+
+	var report errorFile
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse error file: %w", err)
+	}
+	if len(report.Failures) == 0 {
+		return nil, fmt.Errorf("error file lists no failures")
+	}
+
+	var entries []models.PlanEntry
+	seen := make(map[models.PlanEntry]bool)
+	for _, failure := range report.Failures {
+		identifiers := failure.Identifiers
+		if len(identifiers) == 0 {
+			identifiers = []string{""}
+		}
+		for _, id := range identifiers {
+			entry := models.PlanEntry{Blueprint: failure.Blueprint, Identifier: id, NewDatasource: failure.NewDatasource}
+			if !seen[entry] {
+				seen[entry] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// MigrateFromErrorFile re-runs just the failures recorded by --error-file
+func (m *Migrator) MigrateFromErrorFile(path, newDatasourceID string, dryRun bool) (*models.MigrationStats, error) {
+	entries, err := readErrorFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.migrateEntries(entries, "the failures of "+path, newDatasourceID, dryRun)
+}
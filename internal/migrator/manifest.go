@@ -0,0 +1,116 @@
+package migrator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+// maxManifestLineSize bounds a single manifest line, a blueprint with many entities makes a long one
+const maxManifestLineSize = 64 * 1024 * 1024
+
+// manifestWriter appends a JSON line per migrated blueprint as soon as it's done,
+// so a crash leaves a manifest of everything patched up to that point
+type manifestWriter struct {
+	f *os.File
+}
+
+// openManifest opens the manifest for appending, creating it when it doesn't exist.
+// The incomplete last line of an interrupted run is cut off so new lines start clean.
+func openManifest(path string) (*manifestWriter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to open manifest file: %w", err)
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if err := os.Truncate(path, int64(bytes.LastIndexByte(data, '\n')+1)); err != nil {
+			return nil, fmt.Errorf("failed to repair manifest file: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest file: %w", err)
+	}
+	return &manifestWriter{f: f}, nil
+}
+
+// write appends the entry and flushes it to disk before returning
+func (w *manifestWriter) write(entry models.ManifestEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest entry: %w", err)
+	}
+	if _, err := w.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write manifest file: %w", err)
+	}
+	if err := w.f.Sync(); err != nil {
+		return fmt.Errorf("failed to write manifest file: %w", err)
+	}
+	return nil
+}
+
+func (w *manifestWriter) close() error {
+	return w.f.Close()
+}
+
+// readManifest reads the entries of a manifest written by manifestWriter. A malformed last line
+// is what a crash mid-write leaves behind and is dropped with a warning, a malformed earlier line is an error.
+func readManifest(path string, log io.Writer) ([]models.ManifestEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxManifestLineSize)
+
+	var entries []models.ManifestEntry
+	var malformed int // line number of a malformed line, only allowed to be the last one
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if malformed > 0 {
+			return nil, fmt.Errorf("failed to parse manifest file: line %d is malformed", malformed)
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry models.ManifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			malformed = lineNumber
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest file: %w", err)
+	}
+
+	if malformed > 0 {
+		fmt.Fprintf(log, "⚠️  Ignoring the incomplete last line of manifest %s, left by an interrupted run\n", path)
+	}
+	return entries, nil
+}
+
+// completedBlueprints returns the blueprints a manifest records as fully migrated
+func completedBlueprints(entries []models.ManifestEntry) map[string]bool {
+	completed := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Complete {
+			completed[entry.Blueprint] = true
+		}
+	}
+	return completed
+}
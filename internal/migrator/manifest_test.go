@@ -0,0 +1,72 @@
+package migrator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+func TestMigrateResumesFromManifest(t *testing.T) {
+	blueprints := []string{"githubPullRequest", "githubRepository", "githubTeam"}
+	srv, newDatasource := newFixture(t, 30, blueprints...)
+	manifest := filepath.Join(t.TempDir(), "manifest.jsonl")
+
+	// The first run crashes after two of the three blueprints, half way through writing the third's line
+	m, _ := newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest})
+	if _, err := m.Migrate(newDatasource, blueprints[:2], false); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+	f, err := os.OpenFile(manifest, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"blueprint":"githubTeam","newDatasource":"`)
+	f.Close()
+	patched := len(srv.Patches())
+
+	// The second run skips the blueprints the manifest records and migrates the third
+	m, log := newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest})
+	stats, err := m.Migrate(newDatasource, blueprints, false)
+	if err != nil {
+		t.Fatalf("resumed Migrate() failed: %v", err)
+	}
+	if !strings.Contains(log.String(), "Ignoring the incomplete last line") {
+		t.Errorf("the incomplete line wasn't reported:\n%s", log)
+	}
+	if stats.SuccessfulBatches != 1 {
+		t.Errorf("resumed run migrated %d blueprints, want 1", stats.SuccessfulBatches)
+	}
+	for _, p := range srv.Patches()[patched:] {
+		if p.Blueprint != "githubTeam" {
+			t.Errorf("resumed run patched %s again", p.Blueprint)
+		}
+	}
+	for _, bp := range blueprints {
+		if n := oldEntities(srv, bp); n != 0 {
+			t.Errorf("%s has %d entities left on the old datasource", bp, n)
+		}
+	}
+
+	// The repaired manifest lists all three, enough to roll the whole migration back
+	entries, err := readManifest(manifest, log)
+	if err != nil {
+		t.Fatalf("readManifest() failed: %v", err)
+	}
+	completed := completedBlueprints(entries)
+	if len(entries) != 3 || len(completed) != 3 {
+		t.Fatalf("manifest has %d entries for %d completed blueprints, want 3 and 3", len(entries), len(completed))
+	}
+	r, _ := newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest})
+	r.SetInput(strings.NewReader(oldInstallID + "\n"))
+	if _, err := r.Rollback(blueprints); err != nil {
+		t.Fatalf("Rollback() failed: %v", err)
+	}
+	for _, bp := range blueprints {
+		if n := oldEntities(srv, bp); n != 30 {
+			t.Errorf("%s has %d entities back on the old datasource, want 30", bp, n)
+		}
+	}
+}
@@ -1,19 +1,47 @@
 package migrator
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"strings"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/omby8888/port-github-migrator/internal/confirm"
+	"github.com/omby8888/port-github-migrator/internal/jsonout"
 	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/notify"
 	"github.com/omby8888/port-github-migrator/internal/port"
 )
 
+// batchSize is the number of entities patched per bulk request
+const batchSize = 100
+
 // Migrator orchestrates the migration process
 type Migrator struct {
 	client *port.Client
 	config *models.Config
+	in     io.Reader // confirmation answers
+	out    io.Writer // results
+	log    io.Writer // progress, warnings and prompts
+	events notify.EventSink
+	ctx    context.Context // bounds the run, e.g. at --deadline
+
+	// progress tracks the confirmed migration's patched entities, nil before it starts
+	progress *progress
+
+	// consecutiveFailures counts the batches that failed back-to-back, any success resets it
+	consecutiveFailures int
+
+	// previewed holds the blueprints fetched by Preview, until Migrate uses them
+	previewed map[string]blueprintFetch
+
+	// scoped holds the blueprints Preview resolved to migrate, until Migrate uses them
+	scoped *migrationScope
 }
 
 // NewMigrator creates a new migrator
@@ -21,110 +49,591 @@ func NewMigrator(client *port.Client, config *models.Config) *Migrator {
 	return &Migrator{
 		client: client,
 		config: config,
+		in:     os.Stdin,
+		out:    os.Stdout,
+		log:    os.Stderr,
+		ctx:    context.Background(),
 	}
 }
 
+// SetOutput overrides where results and progress messages are written
+func (m *Migrator) SetOutput(out, log io.Writer) {
+	m.out = out
+	m.log = log
+}
+
+// SetContext bounds the migration by ctx, e.g. the --deadline. Once it ends no further batches are
+// patched and the delays between them are cut short, pass the same context to the client with
+// port.WithContext to also cancel the request in flight.
+func (m *Migrator) SetContext(ctx context.Context) {
+	m.ctx = ctx
+}
+
+// SetEventSink sets where the migration events are sent, nil sends none
+func (m *Migrator) SetEventSink(sink notify.EventSink) {
+	m.events = sink
+}
+
+// emitStarted sends the event of a confirmed migration about to patch entities
+func (m *Migrator) emitStarted(newDatasourceID string, blueprints []string, totalEntities int) {
+	notify.Emit(m.events, notify.NewEvent(notify.EventMigrationStarted, "", map[string]interface{}{
+		"blueprints":    blueprints,
+		"totalEntities": totalEntities,
+		"oldDatasource": port.OldDatasource(m.config.OldInstallationID),
+		"newDatasource": newDatasourceID,
+	}))
+}
+
+// emitBlueprintDone sends the event of a blueprint the migration is done with, failed or not
+func (m *Migrator) emitBlueprintDone(blueprintID, newDatasourceID string, patched int, err error) {
+	data := map[string]interface{}{
+		"patched":       patched,
+		"newDatasource": newDatasourceID,
+		"complete":      err == nil,
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	notify.Emit(m.events, notify.NewEvent(notify.EventBlueprintMigrated, blueprintID, data))
+}
+
+// SetInput overrides where the confirmation is read from
+func (m *Migrator) SetInput(in io.Reader) {
+	m.in = in
+}
+
 // Migrate orchestrates the migration process
-func (m *Migrator) Migrate(newDatasourceID string, blueprintID *string, dryRun bool) (*models.MigrationStats, error) {
+func (m *Migrator) Migrate(newDatasourceID string, blueprintIDs []string, dryRun bool) (*models.MigrationStats, error) {
 	stats := &models.MigrationStats{}
 
-	// Get blueprints to migrate
-	var blueprints []string
-	if blueprintID != nil {
-		blueprints = []string{*blueprintID}
-	} else {
-		bps, err := m.client.GetBlueprintsByDataSource(m.config.OldInstallationID)
+	// Get blueprints to migrate, as Preview resolved them when it listed all blueprints
+	scope := m.scoped
+	m.scoped = nil
+	if scope == nil || len(blueprintIDs) > 0 {
+		resolved, err := m.resolveScope(blueprintIDs, dryRun)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get blueprints: %w", err)
+			return nil, err
 		}
-		blueprints = bps
+		scope = resolved
+	}
+	blueprints := scope.blueprints
+	stats.Skipped = scope.skipped
+
+	var manifest *manifestWriter
+	if m.config.ManifestFile != "" && !dryRun {
+		var err error
+		manifest, err = openManifest(m.config.ManifestFile)
+		if err != nil {
+			return nil, err
+		}
+		defer manifest.close()
 	}
 
 	stats.TotalBlueprints = len(blueprints)
 
 	// Show warning and get confirmation
-	fmt.Println()
-	fmt.Println("⚠️  WARNING: This action cannot be undone!")
-	fmt.Println("    Please verify your data with 'get-diff' and 'dry-run' before proceeding.")
-	fmt.Println()
+	if !m.config.NoWarning {
+		fmt.Fprintln(m.log)
+		fmt.Fprintln(m.log, "⚠️  WARNING: This action cannot be undone!")
+		fmt.Fprintln(m.log, "    Please verify your data with 'get-diff' and 'dry-run' before proceeding.")
+		fmt.Fprintln(m.log)
+	}
 
 	totalEntities := 0
 	blueprintCounts := make(map[string]int)
+	projections := make(map[string]models.DatasourceProjection)
+	var planEntries []models.PlanEntry
 
-	// Count entities for each blueprint
-	for _, bp := range blueprints {
-		entities, err := m.client.SearchOldEntitiesByBlueprint(bp, m.config.OldInstallationID)
+	stale := make(map[string]bool)
+	uningested := make(map[string]bool)
+
+	useCachedCounts := m.useCachedCounts(blueprintIDs)
+
+	// Count entities for each blueprint, timing the searches to estimate the run
+	countStart := time.Now()
+	fetched := m.fetchBlueprints(blueprints, useCachedCounts)
+
+	// Processed in blueprint order whatever order the searches completed in
+	for i, bp := range blueprints {
+		f := fetched[i]
+		if useCachedCounts {
+			count := m.config.BlueprintCounts[bp]
+			if count > 0 && m.skipUningested(bp, f.newCount, f.newErr, stats) {
+				uningested[bp] = true
+				recordSkipped(stats, skipUningested, 1, count)
+				continue
+			}
+			blueprintCounts[bp] = count
+			totalEntities += count
+			if p, ok := projectCounts(count, count, f); ok && count > 0 {
+				projections[bp] = p
+			}
+			continue
+		}
+
+		err := f.err
+		if port.IsNotFound(err) {
+			// Listed in the data-sources but the blueprint was since deleted
+			fmt.Fprintf(m.log, "⚠️  Blueprint %s no longer exists, skipping\n", bp)
+			stale[bp] = true
+			stats.StaleBlueprints = append(stats.StaleBlueprints, bp)
+			recordSkipped(stats, skipStale, 1, 0)
+			continue
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to search entities for blueprint %s: %w", bp, err)
 		}
+		entities := m.strictFilter(bp, f.entities, stats)
 		count := len(entities)
+		if count > 0 && m.skipUningested(bp, f.newCount, f.newErr, stats) {
+			uningested[bp] = true
+			recordSkipped(stats, skipUningested, 1, count)
+			continue
+		}
 		blueprintCounts[bp] = count
 		totalEntities += count
+		if p, ok := projectCounts(len(f.entities), count, f); ok && count > 0 {
+			projections[bp] = p
+		}
+
+		sources := make(map[string]int)
+		for _, entity := range entities {
+			oldDatasource := entity.Datasource
+			if oldDatasource == "" {
+				oldDatasource = port.OldDatasource(m.config.OldInstallationID)
+			}
+			sources[oldDatasource]++
+			planEntries = append(planEntries, models.PlanEntry{
+				Blueprint:     bp,
+				Identifier:    entity.Identifier,
+				OldDatasource: oldDatasource,
+				NewDatasource: newDatasourceID,
+			})
+		}
+		m.recordSourceDatasources(bp, sources, stats)
+		if m.config.CheckOrphans {
+			m.recordAtRiskOrphans(bp, entities, f, stats)
+		}
 	}
 
-	fmt.Printf("📊 Total entities affected: %d\n", totalEntities)
+	countDuration := time.Since(countStart)
+
+	stats.TotalEntities = totalEntities
+	fmt.Fprintf(m.log, "📊 Total entities affected: %d\n", totalEntities)
+
+	if m.config.CheckOrphans {
+		m.printAtRiskOrphans(stats)
+		if len(stats.AtRiskOrphans) > 0 && !dryRun && !m.config.AllowOrphans {
+			return stats, fmt.Errorf("❌ %d entities would be orphaned, aborting before any change. Rerun with --allow-orphans to migrate them anyway", countAtRisk(stats))
+		}
+	}
 
 	if totalEntities == 0 {
-		fmt.Println("⚠️  No entities found to migrate. Exiting.")
+		fmt.Fprintln(m.log, "⚠️  No entities found to migrate. Exiting.")
+		if dryRun && m.config.Output == "json" {
+			return stats, m.writeDryRunJSON(newDatasourceID, blueprintCounts, projections, stats)
+		}
+		m.printSkipped(stats)
 		return stats, nil
 	}
 
 	if dryRun {
-		fmt.Println("🔄 DRY RUN MODE - No changes will be made")
+		fmt.Fprintln(m.log, "🔄 DRY RUN MODE - No changes will be made")
+		if !useCachedCounts {
+			m.printEstimate(blueprintCounts, countDuration, min(m.config.CountConcurrency, len(blueprints)))
+		}
+
+		// A plan file is the reviewable output of a dry run, no confirmation needed
+		if m.config.PlanFile != "" {
+			if err := writePlan(m.config.PlanFile, planOperationMigrate, planEntries); err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(m.log, "📝 Wrote %d planned changes to %s\n", len(planEntries), m.config.PlanFile)
+		}
+
+		// A JSON plan is for an external approval system, no confirmation needed either
+		if m.config.Output == "json" {
+			return stats, m.writeDryRunJSON(newDatasourceID, blueprintCounts, projections, stats)
+		}
+		m.printProjection(blueprints, projections)
+		if m.config.PlanFile != "" {
+			return stats, nil
+		}
 	}
 
 	// Get user confirmation
-	fmt.Print("\nType 'yes' to proceed: ")
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(input)
-
-	if input != "yes" {
-		fmt.Println("❌ Migration cancelled.")
+	if !m.confirmMigration(totalEntities) {
+		fmt.Fprintln(m.log, "❌ Migration cancelled.")
 		return stats, nil
 	}
+	if !dryRun {
+		m.emitStarted(newDatasourceID, blueprints, totalEntities)
+		m.progress = newProgress(totalEntities)
+	}
 
 	// Migrate each blueprint
-	for _, bp := range blueprints {
+	patched := 0
+	for i, bp := range blueprints {
+		if stale[bp] || uningested[bp] {
+			continue
+		}
+		if err := m.checkStop(stats); err != nil {
+			m.recordAborted(blueprints[i:], blueprintCounts, newDatasourceID, stats)
+			return stats, err
+		}
 		count := blueprintCounts[bp]
 		
 		// Skip blueprints with no entities
 		if count == 0 {
-			fmt.Printf("\n🔄 Migrating %d entities from blueprint: %s\n", count, bp)
-			fmt.Println("⏭️  No entities to migrate")
+			fmt.Fprintf(m.log, "\n🔄 Migrating %d entities from blueprint: %s\n", count, bp)
+			fmt.Fprintln(m.log, "⏭️  No entities to migrate")
 			continue
 		}
 		
-		fmt.Printf("\n🔄 Migrating %d entities from blueprint: %s\n", count, bp)
+		fmt.Fprintf(m.log, "\n🔄 Migrating %d entities from blueprint: %s\n", count, bp)
+
+		// Titles and relations are captured before patching so they can be restored afterwards
+		var snapshot map[string]port.Entity
+		if m.config.PreserveTitle || m.config.MigrateRelations {
+			entities, err := m.snapshotEntities(bp, stats)
+			if err != nil {
+				stats.Errors = append(stats.Errors, fmt.Sprintf("Failed to snapshot blueprint %s: %v", bp, err))
+			}
+			snapshot = entities
+			if dryRun {
+				m.printRestorePreview(snapshot)
+			}
+		}
 
 		if !dryRun {
-			if err := m.migrateBlueprint(bp, newDatasourceID); err != nil {
+			// Already patched entities no longer match the old datasource search,
+			// so a retry only patches the rest and the confirmed identifiers add up
+			failures := len(stats.Failures)
+			migrated, err := m.migrateBlueprint(bp, newDatasourceID, stats)
+			for attempt := 1; err != nil && !stats.CircuitBreakerTripped && !stats.DeadlineExceeded && !stats.PermissionDenied && attempt <= m.config.BlueprintRetries; attempt++ {
+				fmt.Fprintf(m.log, "⚠️  Blueprint %s failed: %v\n", bp, err)
+				fmt.Fprintf(m.log, "🔁 Retrying blueprint %s in %s (attempt %d of %d)\n", bp, m.config.BlueprintRetryDelay, attempt, m.config.BlueprintRetries)
+				if attempt == 1 {
+					stats.RetriedBlueprints = append(stats.RetriedBlueprints, bp)
+				}
+				if m.sleep(m.config.BlueprintRetryDelay) != nil {
+					m.checkDeadline(stats)
+					break
+				}
+
+				// The retry searches again for everything still left to patch
+				stats.Failures = stats.Failures[:failures]
+				var retried []port.Entity
+				retried, err = m.migrateBlueprint(bp, newDatasourceID, stats)
+				migrated = append(migrated, retried...)
+			}
+			recordPatched(bp, len(migrated), stats)
+			m.emitBlueprintDone(bp, newDatasourceID, len(migrated), err)
+			if manifest != nil && len(migrated) > 0 {
+				if werr := manifest.write(m.newManifestEntry(bp, newDatasourceID, migrated, err == nil)); werr != nil {
+					return stats, werr
+				}
+			}
+			if err != nil {
 				stats.FailedBatches++
 				stats.Errors = append(stats.Errors, fmt.Sprintf("Failed to migrate blueprint %s: %v", bp, err))
+				if len(stats.Failures) == failures {
+					// Failed before any batch, e.g. searching the entities
+					m.consecutiveFailures++
+					stats.Failures = append(stats.Failures, models.FailedBatch{Blueprint: bp, NewDatasource: newDatasourceID, Error: err.Error()})
+				}
 				continue
 			}
+
+			identifiers := make([]string, len(migrated))
+			for i, entity := range migrated {
+				identifiers[i] = entity.Identifier
+			}
+			patched += len(identifiers)
+			if m.config.SummaryOnly {
+				fmt.Fprintf(m.log, "✅ Patched %d entities of %s · %s\n", len(identifiers), bp, m.progress)
+			}
+
+			if m.config.Verify {
+				m.verifyBlueprint(bp, identifiers, newDatasourceID, stats)
+			}
+			if m.config.VerifyIntegrity {
+				m.verifyIntegrity(bp, migrated, stats)
+			}
+
+			if snapshot != nil {
+				m.restoreFromSnapshot(bp, identifiers, snapshot, stats)
+			}
 		}
 
 		stats.SuccessfulBatches++
 	}
 
-	fmt.Println()
-	fmt.Printf("✅ Migration complete! Successfully migrated %d blueprints\n", stats.SuccessfulBatches)
+	if stats.DeadlineExceeded {
+		// The deadline passed in the last blueprint, there was no next one to stop before
+		return stats, m.checkDeadline(stats)
+	}
+
+	fmt.Fprintln(m.out)
+	fmt.Fprintf(m.out, "✅ Migration complete! Successfully migrated %d blueprints\n", stats.SuccessfulBatches)
+	if m.config.SummaryOnly && !dryRun {
+		fmt.Fprintf(m.out, "📊 Patched %d entities, %d blueprints failed\n", patched, stats.FailedBatches)
+	}
+	m.printSkipped(stats)
 
 	return stats, nil
 }
 
-// migrateBlueprint migrates a single blueprint
-func (m *Migrator) migrateBlueprint(blueprintID, newDatasourceID string) error {
+// allBlueprints returns the old installation's blueprints, or those of the cached counts, sorted
+// so a failed run can be resumed from a known position
+func (m *Migrator) allBlueprints() ([]string, error) {
+	var blueprints []string
+	if m.config.BlueprintCounts != nil {
+		// Discovered earlier by get-blueprints --cache-file
+		for bp := range m.config.BlueprintCounts {
+			blueprints = append(blueprints, bp)
+		}
+	} else {
+		discovered, err := m.client.GetBlueprintsByDataSource(m.config.OldInstallationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get blueprints: %w", err)
+		}
+		blueprints = discovered
+	}
+	sort.Strings(blueprints)
+	return blueprints, nil
+}
+
+// useCachedCounts reports whether the cached counts are enough, unless blueprints are named or
+// the plan or the orphan check needs every identifier
+func (m *Migrator) useCachedCounts(blueprintIDs []string) bool {
+	return m.config.BlueprintCounts != nil && len(blueprintIDs) == 0 && m.config.PlanFile == "" && !m.config.CheckOrphans
+}
+
+// namedBlueprints checks that the named blueprints are ones the old installation ingested into,
+// dropping repeated names
+func (m *Migrator) namedBlueprints(names []string) ([]string, error) {
+	discovered, err := m.client.GetBlueprintsByDataSource(m.config.OldInstallationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blueprints: %w", err)
+	}
+	known := make(map[string]bool, len(discovered))
+	for _, bp := range discovered {
+		known[bp] = true
+	}
+
+	var blueprints, unknown []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if !known[name] {
+			unknown = append(unknown, name)
+			continue
+		}
+		blueprints = append(blueprints, name)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("blueprints not managed by the old installation: %v", unknown)
+	}
+	return blueprints, nil
+}
+
+// batchf prints a per-batch progress line unless only summaries are wanted
+func (m *Migrator) batchf(format string, args ...interface{}) {
+	if m.config.SummaryOnly {
+		return
+	}
+	fmt.Fprintf(m.log, format, args...)
+}
+
+// writeDryRunJSON writes the blueprint counts of a dry run as JSON
+func (m *Migrator) writeDryRunJSON(newDatasourceID string, blueprintCounts map[string]int, projections map[string]models.DatasourceProjection, stats *models.MigrationStats) error {
+	summary := models.DryRunSummary{
+		NewDatasource:     newDatasourceID,
+		Blueprints:        blueprintCounts,
+		TotalEntities:     stats.TotalEntities,
+		StaleBlueprints:   stats.StaleBlueprints,
+		Uningested:        stats.UningestedBlueprints,
+		SourceDatasources: stats.SourceDatasources,
+		AtRiskOrphans:     stats.AtRiskOrphans,
+		Projection:        projections,
+		Skipped:           stats.Skipped,
+	}
+
+	encoder := jsonout.NewEncoder(m.out, m.config.JSONCompact)
+	return encoder.Encode(summary)
+}
+
+// skipUningested checks that a blueprint with old entities has some on the new datasource already.
+// None means the new integration likely hasn't ingested it yet and migrating would orphan its entities,
+// so it is skipped unless --allow-uningested.
+func (m *Migrator) skipUningested(blueprintID string, count int, err error, stats *models.MigrationStats) bool {
+	if err != nil {
+		fmt.Fprintf(m.log, "⚠️  Couldn't check the new entities of %s: %v\n", blueprintID, err)
+		return false
+	}
+	if count > 0 {
+		return false
+	}
+
+	stats.UningestedBlueprints = append(stats.UningestedBlueprints, blueprintID)
+	if m.config.AllowUningested {
+		fmt.Fprintf(m.log, "⚠️  %s has no entities on the new datasource yet, migrating it anyway (--allow-uningested)\n", blueprintID)
+		return false
+	}
+	fmt.Fprintf(m.log, "⚠️  %s has no entities on the new datasource yet, the new integration may not have ingested it. Skipping it, migrating would orphan its entities (--allow-uningested to migrate anyway)\n", blueprintID)
+	return true
+}
+
+// recordSourceDatasources keeps the blueprint's entity counts per old datasource,
+// printing them when the entities came from more than one
+func (m *Migrator) recordSourceDatasources(blueprintID string, sources map[string]int, stats *models.MigrationStats) {
+	if len(sources) == 0 {
+		return
+	}
+	if stats.SourceDatasources == nil {
+		stats.SourceDatasources = make(map[string]map[string]int)
+	}
+	stats.SourceDatasources[blueprintID] = sources
+
+	if len(sources) < 2 {
+		return
+	}
+	datasources := make([]string, 0, len(sources))
+	for ds := range sources {
+		datasources = append(datasources, ds)
+	}
+	sort.Strings(datasources)
+
+	fmt.Fprintf(m.log, "📦 %s entities by old datasource:\n", blueprintID)
+	for _, ds := range datasources {
+		fmt.Fprintf(m.log, "       • %s: %d\n", ds, sources[ds])
+	}
+}
+
+// printEstimate prints a rough estimate of the API calls and duration of the real run,
+// based on the latency measured while counting entities
+func (m *Migrator) printEstimate(blueprintCounts map[string]int, countDuration time.Duration, concurrency int) {
+	pages := func(count int) int {
+		if count == 0 {
+			return 1
+		}
+		return (count + port.SearchPageSize - 1) / port.SearchPageSize
+	}
+
+	countCalls := 0
+	searchCalls := 0
+	patchCalls := 0
+	for _, count := range blueprintCounts {
+		countCalls += pages(count)
+		if count == 0 {
+			continue
+		}
+		searchCalls += pages(count)
+		patchCalls += (count + batchSize - 1) / batchSize
+		if m.config.Verify {
+			searchCalls += pages(count)
+		}
+	}
+
+	if countCalls == 0 {
+		return
+	}
+
+	// Counting ran concurrently, the real run searches and patches one blueprint at a time
+	if concurrency > 1 {
+		countDuration *= time.Duration(concurrency)
+	}
+	latency := countDuration / time.Duration(countCalls)
+	// The real run counts again before the prompt
+	totalCalls := countCalls + searchCalls + patchCalls
+	estimate := latency * time.Duration(totalCalls)
+
+	fmt.Fprintf(m.log, "⏱️  Estimate (approximate): %d API calls (%d searches, %d patch batches), ~%s at ~%s per call\n",
+		totalCalls, countCalls+searchCalls, patchCalls, estimate.Round(time.Second), latency.Round(time.Millisecond))
+}
+
+// migrationScope is the blueprints a migration goes through and the counts of those left out
+type migrationScope struct {
+	blueprints []string
+	skipped    map[string]models.SkipCount
+}
+
+// resolveScope returns the blueprints to migrate, all of them unless some are named, without those
+// before --resume-from-blueprint or already done according to the manifest of an earlier run
+func (m *Migrator) resolveScope(blueprintIDs []string, dryRun bool) (*migrationScope, error) {
+	stats := &models.MigrationStats{}
+
+	var blueprints []string
+	if len(blueprintIDs) > 0 {
+		named, err := m.namedBlueprints(blueprintIDs)
+		if err != nil {
+			return nil, err
+		}
+		blueprints = named
+	} else {
+		bps, err := m.allBlueprints()
+		if err != nil {
+			return nil, err
+		}
+		blueprints = bps
+
+		if m.config.ResumeFromBlueprint != "" {
+			resumed, err := m.resumeFrom(blueprints, m.config.ResumeFromBlueprint)
+			if err != nil {
+				return nil, err
+			}
+			recordSkipped(stats, skipResumeFrom, len(blueprints)-len(resumed), 0)
+			blueprints = resumed
+		}
+	}
+
+	if m.config.ManifestFile != "" && !dryRun {
+		entries, err := readManifest(m.config.ManifestFile, m.log)
+		if err != nil {
+			return nil, err
+		}
+		remaining := m.skipCompleted(blueprints, completedBlueprints(entries))
+		recordSkipped(stats, skipManifest, len(blueprints)-len(remaining), 0)
+		blueprints = remaining
+	}
+
+	return &migrationScope{blueprints: blueprints, skipped: stats.Skipped}, nil
+}
+
+// resumeFrom drops the blueprints sorted before the resume blueprint
+func (m *Migrator) resumeFrom(blueprints []string, resumeBlueprint string) ([]string, error) {
+	for i, bp := range blueprints {
+		if bp != resumeBlueprint {
+			continue
+		}
+
+		if i > 0 {
+			fmt.Fprintf(m.log, "⏭️  Resuming from %s, skipping %d blueprints:\n", resumeBlueprint, i)
+			for _, skipped := range blueprints[:i] {
+				fmt.Fprintf(m.log, "       • %s\n", skipped)
+			}
+		}
+		return blueprints[i:], nil
+	}
+
+	return nil, fmt.Errorf("blueprint %s to resume from is not managed by the old installation", resumeBlueprint)
+}
+
+// migrateBlueprint migrates a single blueprint and returns the patched entities as they were before
+func (m *Migrator) migrateBlueprint(blueprintID, newDatasourceID string, stats *models.MigrationStats) ([]port.Entity, error) {
 	// Get old entities
-	entities, err := m.client.SearchOldEntitiesByBlueprint(blueprintID, m.config.OldInstallationID)
+	entities, err := m.searchOldEntities(blueprintID, stats)
 	if err != nil {
-		return fmt.Errorf("failed to search entities: %w", err)
+		return nil, fmt.Errorf("failed to search entities: %w", err)
 	}
 
 	if len(entities) == 0 {
-		fmt.Println("⏭️  No entities to migrate")
-		return nil
+		fmt.Fprintln(m.log, "⏭️  No entities to migrate")
+		return nil, nil
 	}
 
 	// Extract identifiers
@@ -133,22 +642,524 @@ func (m *Migrator) migrateBlueprint(blueprintID, newDatasourceID string) error {
 		identifiers[i] = entity.Identifier
 	}
 
-	// Patch in batches of 100
-	batchSize := 100
+	confirmed, err := m.patchIdentifiers(blueprintID, identifiers, newDatasourceID, stats)
+
+	byIdentifier := make(map[string]port.Entity, len(entities))
+	for _, entity := range entities {
+		byIdentifier[entity.Identifier] = entity
+	}
+	patched := make([]port.Entity, 0, len(confirmed))
+	for _, id := range confirmed {
+		patched = append(patched, byIdentifier[id])
+	}
+	return patched, err
+}
+
+// newManifestEntry records the patched entities of a blueprint with the datasource they had before
+func (m *Migrator) newManifestEntry(blueprintID, newDatasourceID string, patched []port.Entity, complete bool) models.ManifestEntry {
+	entry := models.ManifestEntry{
+		Blueprint:     blueprintID,
+		NewDatasource: newDatasourceID,
+		Complete:      complete,
+		CompletedAt:   time.Now().UTC(),
+	}
+	for _, entity := range patched {
+		oldDatasource := entity.Datasource
+		if oldDatasource == "" {
+			oldDatasource = port.OldDatasource(m.config.OldInstallationID)
+		}
+		entry.Entities = append(entry.Entities, models.ManifestEntity{
+			Identifier:    entity.Identifier,
+			OldDatasource: oldDatasource,
+			Checksum:      entityChecksum(entity),
+		})
+	}
+	return entry
+}
+
+// skipCompleted drops the blueprints an earlier run's manifest records as fully migrated
+func (m *Migrator) skipCompleted(blueprints []string, completed map[string]bool) []string {
+	var remaining, skipped []string
+	for _, bp := range blueprints {
+		if completed[bp] {
+			skipped = append(skipped, bp)
+			continue
+		}
+		remaining = append(remaining, bp)
+	}
+
+	if len(skipped) > 0 {
+		fmt.Fprintf(m.log, "⏭️  Skipping %d blueprints already migrated according to %s:\n", len(skipped), m.config.ManifestFile)
+		for _, bp := range skipped {
+			fmt.Fprintf(m.log, "       • %s\n", bp)
+		}
+	}
+	return remaining
+}
+
+// searchOldEntities searches the blueprint's old entities. In strict mode it drops the
+// entities the contains search matched whose datasource isn't exactly the old one.
+func (m *Migrator) searchOldEntities(blueprintID string, stats *models.MigrationStats) ([]port.Entity, error) {
+	entities, err := m.client.SearchOldEntitiesByBlueprint(blueprintID, m.config.OldInstallationID)
+	if err != nil {
+		return nil, err
+	}
+	return m.strictFilter(blueprintID, entities, stats), nil
+}
+
+// strictFilter drops, in strict mode, the entities whose datasource isn't exactly the old one and records them
+func (m *Migrator) strictFilter(blueprintID string, entities []port.Entity, stats *models.MigrationStats) []port.Entity {
+	if !m.config.Strict {
+		return entities
+	}
+
+	exact, loose := port.SplitByDatasource(entities, port.OldDatasource(m.config.OldInstallationID))
+	if len(loose) == 0 {
+		return exact
+	}
+
+	fmt.Fprintf(m.log, "⚠️  Strict mode: skipping %d entities of %s whose datasource isn't %s:\n", len(loose), blueprintID, port.OldDatasource(m.config.OldInstallationID))
+	if stats.LooseMatches == nil {
+		stats.LooseMatches = make(map[string][]string)
+	}
+	seen := make(map[string]bool)
+	for _, id := range stats.LooseMatches[blueprintID] {
+		seen[id] = true
+	}
+	for _, e := range loose {
+		fmt.Fprintf(m.log, "       • %s (%s)\n", e.Identifier, e.Datasource)
+		if !seen[e.Identifier] {
+			stats.LooseMatches[blueprintID] = append(stats.LooseMatches[blueprintID], e.Identifier)
+			recordSkipped(stats, skipStrict, 0, 1)
+		}
+	}
+	return exact
+}
+
+// searchOldEntitiesSettled searches the blueprint's old entities, re-searching up to
+// --retry-on-404-search times when the result is empty or the blueprint 404s. Right after an
+// integration change Port's search may lag behind, and an early "nothing to migrate" is worse
+// than a short wait. A genuinely empty blueprint just costs the retries.
+func (m *Migrator) searchOldEntitiesSettled(blueprintID string, stats *models.MigrationStats) ([]port.Entity, error) {
+	entities, err := m.fetchOldEntitiesSettled(blueprintID)
+	if err != nil {
+		return nil, err
+	}
+	return m.strictFilter(blueprintID, entities, stats), nil
+}
+
+// fetchOldEntitiesSettled is searchOldEntitiesSettled without the strict filter, it doesn't touch
+// the stats so blueprints can be fetched concurrently
+func (m *Migrator) fetchOldEntitiesSettled(blueprintID string) ([]port.Entity, error) {
+	entities, err := m.client.SearchOldEntitiesByBlueprint(blueprintID, m.config.OldInstallationID)
+	for attempt := 1; attempt <= m.config.EmptySearchRetries; attempt++ {
+		empty := err == nil && len(entities) == 0
+		if !empty && !port.IsNotFound(err) {
+			break
+		}
+		fmt.Fprintf(m.log, "⏳ Search of %s found nothing, searching again in %s (attempt %d of %d)\n", blueprintID, m.config.EmptySearchDelay, attempt, m.config.EmptySearchRetries)
+		if m.sleep(m.config.EmptySearchDelay) != nil {
+			break
+		}
+		entities, err = m.client.SearchOldEntitiesByBlueprint(blueprintID, m.config.OldInstallationID)
+	}
+	return entities, err
+}
+
+// blueprintFetch holds what the counting loop needs to know about a blueprint
+type blueprintFetch struct {
+	entities []port.Entity // old entities, before the strict filter
+	err      error
+
+	newCount int // entities on the new datasource, only counted when there are old ones
+	newErr   error
+
+	newIDs    map[string]bool // identifiers on the new datasource, only searched by --check-orphans
+	newIDsErr error
+}
+
+// fetchBlueprints searches the old entities of the blueprints, unless the counts are cached, and counts
+// their new entities with up to --count-concurrency blueprints at once. The client's rate limiter and
+// token handling are shared by the workers. Blueprints fetched by Preview aren't searched again.
+func (m *Migrator) fetchBlueprints(blueprints []string, useCachedCounts bool) []blueprintFetch {
+	concurrency := m.config.CountConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	fetched := make([]blueprintFetch, len(blueprints))
+	var pending []int
+	for i, bp := range blueprints {
+		if f, ok := m.previewed[bp]; ok {
+			fetched[i] = f
+			delete(m.previewed, bp)
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				bp := blueprints[i]
+				f := &fetched[i]
+
+				hasOld := m.config.BlueprintCounts[bp] > 0
+				if !useCachedCounts {
+					f.entities, f.err = m.fetchOldEntitiesSettled(bp)
+					hasOld = f.err == nil && len(f.entities) > 0
+				}
+				if hasOld {
+					f.newCount, f.newErr = m.client.CountNewEntities(bp, m.config.NewInstallationID)
+				}
+				if hasOld && m.config.CheckOrphans {
+					f.newIDs, f.newIDsErr = m.searchNewIdentifiers(bp)
+				}
+			}
+		}()
+	}
+	for _, i := range pending {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return fetched
+}
+
+// snapshotEntities returns the blueprint's old entities by identifier
+func (m *Migrator) snapshotEntities(blueprintID string, stats *models.MigrationStats) (map[string]port.Entity, error) {
+	entities, err := m.searchOldEntities(blueprintID, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]port.Entity, len(entities))
+	for _, entity := range entities {
+		snapshot[entity.Identifier] = entity
+	}
+	return snapshot, nil
+}
+
+// restoreFromSnapshot patches the old title (--preserve-title) and relations (--migrate-relations)
+// back onto migrated entities where they now differ, with a single patch per entity
+func (m *Migrator) restoreFromSnapshot(blueprintID string, identifiers []string, snapshot map[string]port.Entity, stats *models.MigrationStats) {
+	if len(identifiers) == 0 {
+		return
+	}
+
+	entities, err := m.client.SearchNewEntitiesByBlueprint(blueprintID, m.config.NewInstallationID)
+	if err != nil {
+		stats.Errors = append(stats.Errors, fmt.Sprintf("Failed to read migrated entities of blueprint %s: %v", blueprintID, err))
+		return
+	}
+
+	current := make(map[string]port.Entity, len(entities))
+	for _, entity := range entities {
+		current[entity.Identifier] = entity
+	}
+
+	restoredTitles, restoredRelations := 0, 0
+	for _, id := range identifiers {
+		old, known := snapshot[id]
+		now, migrated := current[id]
+		if !known || !migrated {
+			continue
+		}
+
+		patch := make(map[string]interface{})
+		if m.config.PreserveTitle && old.Title != "" && old.Title != now.Title {
+			patch["title"] = old.Title
+		}
+		if m.config.MigrateRelations && hasRelations(old.Relations) && !reflect.DeepEqual(old.Relations, now.Relations) {
+			patch["relations"] = old.Relations
+		}
+		if len(patch) == 0 {
+			continue
+		}
+
+		if err := m.client.PatchEntity(blueprintID, id, patch); err != nil {
+			fmt.Fprintf(m.log, "❌ Failed to restore %s: %v\n", id, err)
+			stats.Errors = append(stats.Errors, fmt.Sprintf("Failed to restore %s/%s: %v", blueprintID, id, err))
+			continue
+		}
+		if _, ok := patch["title"]; ok {
+			restoredTitles++
+		}
+		if _, ok := patch["relations"]; ok {
+			restoredRelations++
+		}
+	}
+
+	if restoredTitles > 0 {
+		fmt.Fprintf(m.log, "🏷️  Restored the old title of %d entities\n", restoredTitles)
+	}
+	if restoredRelations > 0 {
+		fmt.Fprintf(m.log, "🔗 Restored the old relations of %d entities\n", restoredRelations)
+	}
+	stats.RestoredTitles += restoredTitles
+	stats.RestoredRelations += restoredRelations
+}
+
+// printRestorePreview tells what a dry run would restore after patching
+func (m *Migrator) printRestorePreview(snapshot map[string]port.Entity) {
+	if m.config.PreserveTitle {
+		fmt.Fprintf(m.log, "🏷️  Would restore the title of any of %d entities retitled after patching\n", len(snapshot))
+	}
+	if m.config.MigrateRelations {
+		withRelations := 0
+		for _, entity := range snapshot {
+			if hasRelations(entity.Relations) {
+				withRelations++
+			}
+		}
+		fmt.Fprintf(m.log, "🔗 Would restore the old relations of any of %d entities with relations changed after patching\n", withRelations)
+	}
+}
+
+// hasRelations reports whether an entity's relations hold at least one relation
+func hasRelations(relations interface{}) bool {
+	m, ok := relations.(map[string]interface{})
+	return ok && len(m) > 0
+}
+
+// verifyBlueprint confirms the patched identifiers now carry the new datasource
+func (m *Migrator) verifyBlueprint(blueprintID string, identifiers []string, newDatasourceID string, stats *models.MigrationStats) {
+	if len(identifiers) == 0 {
+		return
+	}
+
+	entities, err := m.client.SearchNewEntitiesByBlueprint(blueprintID, m.config.NewInstallationID)
+	if err != nil {
+		stats.Errors = append(stats.Errors, fmt.Sprintf("Failed to verify blueprint %s: %v", blueprintID, err))
+		return
+	}
+
+	migrated := make(map[string]bool)
+	for _, entity := range entities {
+		// The search matches on substrings, the actual datasource must be the exact one we patched to
+		if entity.Datasource != "" && entity.Datasource != newDatasourceID {
+			continue
+		}
+		migrated[entity.Identifier] = true
+	}
+
+	var unverified []string
+	for _, id := range identifiers {
+		if !migrated[id] {
+			unverified = append(unverified, id)
+		}
+	}
+
+	if len(unverified) == 0 {
+		fmt.Fprintf(m.log, "🔎 Verified %d entities now have the new datasource\n", len(identifiers))
+		return
+	}
+
+	fmt.Fprintf(m.log, "❌ %d of %d patched entities don't have the new datasource:\n", len(unverified), len(identifiers))
+	for _, id := range unverified {
+		fmt.Fprintf(m.log, "       • %s\n", id)
+	}
+
+	if stats.UnverifiedEntities == nil {
+		stats.UnverifiedEntities = make(map[string][]string)
+	}
+	stats.UnverifiedEntities[blueprintID] = unverified
+}
+
+// patchIdentifiers patches the datasource of the given identifiers in batches
+// and returns the identifiers Port confirmed
+func (m *Migrator) patchIdentifiers(blueprintID string, identifiers []string, newDatasourceID string, stats *models.MigrationStats) ([]string, error) {
+	var confirmed []string
+
+	// Patch in batches of batchSize
 	for i := 0; i < len(identifiers); i += batchSize {
 		end := i + batchSize
 		if end > len(identifiers) {
 			end = len(identifiers)
 		}
 
+		// Paced so the batches don't burst the API
+		// A delay cut short by the deadline is reported by checkStop
+		if i > 0 && m.config.BatchDelay > 0 {
+			m.sleep(m.config.BatchDelay)
+		}
+
+		if err := m.checkStop(stats); err != nil {
+			stats.Failures = append(stats.Failures, models.FailedBatch{Blueprint: blueprintID, Identifiers: identifiers[i:], NewDatasource: newDatasourceID, Error: err.Error()})
+			return confirmed, err
+		}
+
 		batch := identifiers[i:end]
-		if err := m.client.PatchEntitiesDatasourceBulk(blueprintID, batch, newDatasourceID); err != nil {
-			return fmt.Errorf("failed to patch batch: %w", err)
+		result, err := m.client.PatchEntitiesDatasourceBulk(blueprintID, batch, newDatasourceID)
+		if result != nil {
+			confirmed = append(confirmed, result.Confirmed...)
+		}
+		if err != nil || len(result.Confirmed) == 0 && len(result.Failed) > 0 {
+			m.consecutiveFailures++
+		} else {
+			m.consecutiveFailures = 0
+		}
+		if port.IsForbidden(err) && !stats.PermissionDenied {
+			// Every other patch would be refused too
+			stats.PermissionDenied = true
+			stats.AbortReason = "aborted, Port refused to patch entities (403), the credentials may be read-only"
+			fmt.Fprintln(m.log, "🛑 Port refused to patch entities with insufficient permissions, your credentials may be read-only. Aborting the migration, check them with 'validate'.")
+		}
+		if err != nil {
+			// A batch cancelled at the deadline stops the migration like one never sent
+			m.checkDeadline(stats)
+			// The failed batch and every later one are left unpatched
+			unpatched := unconfirmed(identifiers[i:], result)
+			stats.Failures = append(stats.Failures, models.FailedBatch{Blueprint: blueprintID, Identifiers: unpatched, NewDatasource: newDatasourceID, Error: err.Error()})
+			return confirmed, fmt.Errorf("failed to patch batch: %w", err)
+		}
+
+		if result.Requests > 1 {
+			fmt.Fprintf(m.log, "⚠️  Batch exceeded the request body size limit and was split into %d requests\n", result.Requests)
 		}
 
-		fmt.Printf("✅ Successfully patched %d entities\n", len(batch))
+		m.progress.add(len(result.Confirmed) + len(result.Failed))
+		m.batchf("✅ Successfully patched %d entities · %s\n", len(result.Confirmed), m.progress)
+		for id, message := range result.Failed {
+			fmt.Fprintf(m.log, "❌ Failed to patch %s: %s\n", id, message)
+			stats.Errors = append(stats.Errors, fmt.Sprintf("Failed to patch entity %s/%s: %s", blueprintID, id, message))
+			stats.Failures = append(stats.Failures, models.FailedBatch{Blueprint: blueprintID, Identifiers: []string{id}, NewDatasource: newDatasourceID, Error: message})
+		}
 	}
 
-	return nil
+	return confirmed, nil
+}
+
+// recordPatched counts the blueprint's patched entities in the stats
+func recordPatched(blueprintID string, patched int, stats *models.MigrationStats) {
+	if patched == 0 {
+		return
+	}
+	if stats.PatchedEntities == nil {
+		stats.PatchedEntities = make(map[string]int)
+	}
+	stats.PatchedEntities[blueprintID] += patched
 }
 
+// unconfirmed returns the identifiers a partially applied bulk patch didn't confirm
+func unconfirmed(identifiers []string, result *port.BulkPatchResult) []string {
+	if result == nil || len(result.Confirmed) == 0 {
+		return identifiers
+	}
+
+	confirmed := make(map[string]bool, len(result.Confirmed))
+	for _, id := range result.Confirmed {
+		confirmed[id] = true
+	}
+	var rest []string
+	for _, id := range identifiers {
+		if !confirmed[id] {
+			rest = append(rest, id)
+		}
+	}
+	return rest
+}
+
+// recordAborted records the blueprints with entities the circuit breaker kept from being migrated as failures
+func (m *Migrator) recordAborted(blueprints []string, blueprintCounts map[string]int, newDatasourceID string, stats *models.MigrationStats) {
+	for _, bp := range blueprints {
+		if blueprintCounts[bp] == 0 {
+			continue
+		}
+		stats.Failures = append(stats.Failures, models.FailedBatch{
+			Blueprint:     bp,
+			NewDatasource: newDatasourceID,
+			Error:         "not attempted, " + stats.AbortReason,
+		})
+	}
+}
+
+// checkStop stops the migration before the next blueprint or batch once the --deadline passed,
+// a circuit breaker tripped or Port refused a patch for lack of permissions
+func (m *Migrator) checkStop(stats *models.MigrationStats) error {
+	if stats.PermissionDenied {
+		return errors.New(stats.AbortReason)
+	}
+	if err := m.checkDeadline(stats); err != nil {
+		return err
+	}
+	return m.checkFailureBudget(stats)
+}
+
+// checkDeadline stops the migration once the context ended at --deadline. The returned error wraps
+// context.DeadlineExceeded so the command exits with the timeout status.
+func (m *Migrator) checkDeadline(stats *models.MigrationStats) error {
+	if m.ctx.Err() == nil {
+		return nil
+	}
+
+	if !stats.DeadlineExceeded {
+		stats.DeadlineExceeded = true
+		stats.AbortReason = "stopped at the --deadline"
+		if deadline, ok := m.ctx.Deadline(); ok {
+			stats.AbortReason = fmt.Sprintf("stopped at the --deadline of %s", deadline.Format(time.RFC3339))
+		}
+		fmt.Fprintf(m.log, "⏰ The deadline passed, stopping the migration (%s). Rerun to migrate the rest.\n", stats.AbortReason)
+	}
+	return fmt.Errorf("%s: %w", stats.AbortReason, m.ctx.Err())
+}
+
+// sleep waits for d, returning the context's error early once the context ends
+func (m *Migrator) sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-m.ctx.Done():
+		return m.ctx.Err()
+	}
+}
+
+// checkFailureBudget trips the circuit breaker once more requests failed than --max-failures allows,
+// or once --max-consecutive-failures batches failed in a row
+func (m *Migrator) checkFailureBudget(stats *models.MigrationStats) error {
+	if stats.CircuitBreakerTripped {
+		return errors.New(stats.AbortReason)
+	}
+
+	if m.config.MaxConsecutiveFailures > 0 && m.consecutiveFailures >= m.config.MaxConsecutiveFailures {
+		stats.CircuitBreakerTripped = true
+		stats.AbortReason = fmt.Sprintf("aborted after %d consecutive failed batches (--max-consecutive-failures %d)", m.consecutiveFailures, m.config.MaxConsecutiveFailures)
+		fmt.Fprintf(m.log, "🛑 %d batches failed in a row. Something systemic, such as a bad datasource, is wrong, aborting the migration.\n", m.consecutiveFailures)
+		return errors.New(stats.AbortReason)
+	}
+
+	if m.config.MaxFailures <= 0 {
+		return nil
+	}
+
+	failed := m.client.FailedRequests()
+	if failed <= m.config.MaxFailures {
+		return nil
+	}
+
+	stats.CircuitBreakerTripped = true
+	stats.AbortReason = fmt.Sprintf("aborted after %d failed requests (--max-failures %d)", failed, m.config.MaxFailures)
+	fmt.Fprintf(m.log, "🛑 %d requests failed, more than --max-failures %d. The Port API looks degraded, aborting the migration.\n", failed, m.config.MaxFailures)
+	return errors.New(stats.AbortReason)
+}
+
+// confirmMigration asks the user to type 'yes' before making changes, skipping the prompt
+// when fewer entities than --no-prompt-below are affected
+func (m *Migrator) confirmMigration(totalEntities int) bool {
+	prompt := confirm.Prompt{
+		Timeout: m.config.ConfirmTimeout,
+		Skip:    totalEntities < m.config.NoPromptBelow,
+	}
+	if prompt.Skip {
+		fmt.Fprintf(m.log, "\n⏩ %d entities is below --no-prompt-below %d, proceeding without confirmation\n", totalEntities, m.config.NoPromptBelow)
+	}
+	return prompt.Ask(m.in, m.log)
+}
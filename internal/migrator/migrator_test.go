@@ -0,0 +1,144 @@
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/port"
+	"github.com/omby8888/port-github-migrator/internal/port/porttest"
+)
+
+const (
+	oldInstallID = "12345"
+	newInstallID = "67890"
+	version      = "1.0.0"
+)
+
+// newFixture starts a fake Port where the old installation ingested the blueprints, each with n old
+// entities and one already on the new datasource so the new integration counts as having ingested it
+func newFixture(t *testing.T, n int, blueprints ...string) (*porttest.Server, string) {
+	t.Helper()
+	srv := porttest.NewServer()
+	t.Cleanup(srv.Close)
+
+	srv.AddDataSource(oldInstallID, blueprints...)
+	for _, bp := range blueprints {
+		addBlueprint(srv, bp, n, true)
+	}
+	return srv, srv.Client().NewDatasource(version, newInstallID)
+}
+
+// addBlueprint adds n old entities of a blueprint, and one on the new datasource when the new
+// integration ingested it
+func addBlueprint(srv *porttest.Server, blueprint string, n int, ingested bool) {
+	for i := 0; i < n; i++ {
+		srv.AddEntities(blueprint, port.Entity{Identifier: fmt.Sprintf("%s-%d", blueprint, i), Datasource: port.OldDatasource(oldInstallID)})
+	}
+	if ingested {
+		srv.AddEntities(blueprint, port.Entity{Identifier: blueprint + "-ingested", Datasource: srv.Client().NewDatasource(version, newInstallID)})
+	}
+}
+
+// newTestMigrator returns a migrator for the fixture's installations that confirms the migration,
+// with its progress written to the returned buffer
+func newTestMigrator(client *port.Client, config *models.Config) (*Migrator, *bytes.Buffer) {
+	config.OldInstallationID = oldInstallID
+	config.NewInstallationID = newInstallID
+	config.NoWarning = true
+
+	m := NewMigrator(client, config)
+	var log bytes.Buffer
+	m.SetOutput(io.Discard, &log)
+	m.SetInput(strings.NewReader("yes\n"))
+	return m, &log
+}
+
+// oldEntities counts the blueprint's entities still on the old datasource
+func oldEntities(srv *porttest.Server, blueprint string) int {
+	n := 0
+	for _, e := range srv.Entities(blueprint) {
+		if e.Datasource == port.OldDatasource(oldInstallID) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestMigrate(t *testing.T) {
+	srv, newDatasource := newFixture(t, 150, "githubPullRequest", "githubRepository")
+
+	m, _ := newTestMigrator(srv.Client(), &models.Config{})
+	stats, err := m.Migrate(newDatasource, nil, false)
+	if err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	if stats.SuccessfulBatches != 2 || stats.FailedBatches != 0 {
+		t.Errorf("migrated %d blueprints with %d failed, want 2 and 0", stats.SuccessfulBatches, stats.FailedBatches)
+	}
+	for _, bp := range []string{"githubPullRequest", "githubRepository"} {
+		if n := oldEntities(srv, bp); n != 0 {
+			t.Errorf("%s has %d entities left on the old datasource", bp, n)
+		}
+	}
+	// 150 entities per blueprint take two batches of up to 100
+	if got := len(srv.Patches()); got != 4 {
+		t.Errorf("got %d bulk patches, want 4", got)
+	}
+}
+
+func TestMigrateStopsAtDeadline(t *testing.T) {
+	srv, newDatasource := newFixture(t, 250, "githubRepository")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	// The delay after the first batch outlasts the deadline
+	m, _ := newTestMigrator(srv.Client(port.WithContext(ctx)), &models.Config{BatchDelay: time.Hour})
+	m.SetContext(ctx)
+
+	start := time.Now()
+	stats, err := m.Migrate(newDatasource, nil, false)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("Migrate() took %s, the batch delay wasn't cut short at the deadline", elapsed)
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Migrate() = %v, want the deadline", err)
+	}
+	if !stats.DeadlineExceeded || !strings.Contains(stats.AbortReason, "--deadline") {
+		t.Errorf("stats don't record the deadline: exceeded %v, reason %q", stats.DeadlineExceeded, stats.AbortReason)
+	}
+	if got := len(srv.Patches()); got != 1 {
+		t.Errorf("got %d bulk patches, want only the one before the deadline", got)
+	}
+	unpatched := 0
+	for _, f := range stats.Failures {
+		unpatched += len(f.Identifiers)
+	}
+	if left := oldEntities(srv, "githubRepository"); unpatched != left || left != 150 {
+		t.Errorf("failures list %d identifiers, %d are left on the old datasource, want 150", unpatched, left)
+	}
+}
+
+func TestEmptySearchDelayCutShortByDeadline(t *testing.T) {
+	// Nothing to migrate, so each search comes back empty and waits to search again
+	srv, newDatasource := newFixture(t, 0, "githubRepository")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	m, _ := newTestMigrator(srv.Client(port.WithContext(ctx)), &models.Config{EmptySearchRetries: 3, EmptySearchDelay: time.Hour})
+	m.SetContext(ctx)
+
+	start := time.Now()
+	m.Migrate(newDatasource, nil, false)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Migrate() took %s, the search retry delay wasn't cut short at the deadline", elapsed)
+	}
+}
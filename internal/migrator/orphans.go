@@ -0,0 +1,92 @@
+package migrator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+// orphanPreviewLimit is how many at-risk identifiers of a blueprint --check-orphans lists
+const orphanPreviewLimit = 10
+
+// searchNewIdentifiers returns the identifiers of the blueprint's entities on the new datasource
+func (m *Migrator) searchNewIdentifiers(blueprintID string) (map[string]bool, error) {
+	entities, err := m.client.SearchNewEntitiesByBlueprint(blueprintID, m.config.NewInstallationID)
+	if err != nil {
+		return nil, err
+	}
+
+	identifiers := make(map[string]bool, len(entities))
+	for _, entity := range entities {
+		identifiers[entity.Identifier] = true
+	}
+	return identifiers, nil
+}
+
+// recordAtRiskOrphans records the old entities the new integration hasn't ingested a counterpart of.
+// Once their datasource is changed nothing updates or deletes them, they are orphaned.
+func (m *Migrator) recordAtRiskOrphans(blueprintID string, entities []port.Entity, f blueprintFetch, stats *models.MigrationStats) {
+	if len(entities) == 0 {
+		return
+	}
+	if f.newIDsErr != nil {
+		stats.Errors = append(stats.Errors, fmt.Sprintf("Failed to check blueprint %s for orphans: %v", blueprintID, f.newIDsErr))
+		fmt.Fprintf(m.log, "⚠️  Couldn't check %s for orphans: %v\n", blueprintID, f.newIDsErr)
+		return
+	}
+
+	var atRisk []string
+	for _, entity := range entities {
+		if !f.newIDs[entity.Identifier] {
+			atRisk = append(atRisk, entity.Identifier)
+		}
+	}
+	if len(atRisk) == 0 {
+		return
+	}
+
+	sort.Strings(atRisk)
+	if stats.AtRiskOrphans == nil {
+		stats.AtRiskOrphans = make(map[string][]string)
+	}
+	stats.AtRiskOrphans[blueprintID] = atRisk
+}
+
+// printAtRiskOrphans reports the entities found by --check-orphans per blueprint
+func (m *Migrator) printAtRiskOrphans(stats *models.MigrationStats) {
+	if len(stats.AtRiskOrphans) == 0 {
+		fmt.Fprintln(m.log, "✅ No entities at risk of being orphaned, every old entity has a counterpart on the new datasource")
+		return
+	}
+
+	blueprints := make([]string, 0, len(stats.AtRiskOrphans))
+	for bp := range stats.AtRiskOrphans {
+		blueprints = append(blueprints, bp)
+	}
+	sort.Strings(blueprints)
+
+	fmt.Fprintf(m.log, "\n🚸 %d entities have no counterpart on the new datasource and would be orphaned by the migration:\n", countAtRisk(stats))
+	for _, bp := range blueprints {
+		identifiers := stats.AtRiskOrphans[bp]
+		fmt.Fprintf(m.log, "   %s: %d\n", bp, len(identifiers))
+		for i, id := range identifiers {
+			if i == orphanPreviewLimit {
+				fmt.Fprintf(m.log, "       ... and %d more\n", len(identifiers)-orphanPreviewLimit)
+				break
+			}
+			fmt.Fprintf(m.log, "       • %s\n", id)
+		}
+	}
+	fmt.Fprintln(m.log)
+}
+
+// countAtRisk returns the number of entities found by --check-orphans
+func countAtRisk(stats *models.MigrationStats) int {
+	total := 0
+	for _, identifiers := range stats.AtRiskOrphans {
+		total += len(identifiers)
+	}
+	return total
+}
@@ -0,0 +1,367 @@
+package migrator
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/port"
+	"github.com/omby8888/port-github-migrator/internal/textwidth"
+)
+
+var planHeader = []string{"blueprint", "identifier", "old_datasource", "new_datasource"}
+
+// Plan operations, a plan of one can't be executed as the other
+const (
+	planOperationMigrate  = "migrate"
+	planOperationRollback = "rollback"
+)
+
+// isJSONPlan tells a JSON plan file from a CSV one by its extension
+func isJSONPlan(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// newMigrationPlan groups the planned changes into a blueprint's transitions between two datasources,
+// in the order they were planned
+func newMigrationPlan(operation string, entries []models.PlanEntry) models.MigrationPlan {
+	plan := models.MigrationPlan{
+		SchemaVersion: models.PlanSchemaVersion,
+		Operation:     operation,
+		Transitions:   []models.PlanTransition{},
+	}
+
+	index := make(map[models.PlanEntry]int) // entry without identifier -> its transition
+	for _, entry := range entries {
+		key := models.PlanEntry{Blueprint: entry.Blueprint, OldDatasource: entry.OldDatasource, NewDatasource: entry.NewDatasource}
+		i, ok := index[key]
+		if !ok {
+			i = len(plan.Transitions)
+			index[key] = i
+			plan.Transitions = append(plan.Transitions, models.PlanTransition{
+				Blueprint:      entry.Blueprint,
+				Identifiers:    []string{},
+				FromDatasource: entry.OldDatasource,
+				ToDatasource:   entry.NewDatasource,
+			})
+		}
+		if entry.Identifier != "" {
+			plan.Transitions[i].Identifiers = append(plan.Transitions[i].Identifiers, entry.Identifier)
+		}
+	}
+	return plan
+}
+
+// writePlan writes the planned datasource changes for review, as a versioned JSON plan
+// when the path ends in .json and as CSV otherwise
+func writePlan(path, operation string, entries []models.PlanEntry) error {
+	if isJSONPlan(path) {
+		data, _ := json.MarshalIndent(newMigrationPlan(operation, entries), "", "  ")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write plan file: %w", err)
+		}
+		return nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create plan file: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.Write(planHeader); err != nil {
+		return fmt.Errorf("failed to write plan file: %w", err)
+	}
+	for _, entry := range entries {
+		row := []string{entry.Blueprint, entry.Identifier, entry.OldDatasource, entry.NewDatasource}
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("failed to write plan file: %w", err)
+		}
+	}
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write plan file: %w", err)
+	}
+	return nil
+}
+
+// readJSONPlan reads a reviewed JSON plan written by writePlan, refusing other schema versions
+// and operations
+func readJSONPlan(path, operation string) ([]models.PlanEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plan file: %w", err)
+	}
+
+	var plan models.MigrationPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file: %w", err)
+	}
+	if plan.SchemaVersion != models.PlanSchemaVersion {
+		return nil, fmt.Errorf("plan file has schema version %d, expected %d", plan.SchemaVersion, models.PlanSchemaVersion)
+	}
+	if plan.Operation != operation {
+		return nil, fmt.Errorf("plan file is a %s plan, expected a %s plan", plan.Operation, operation)
+	}
+	if len(plan.Transitions) == 0 {
+		return nil, fmt.Errorf("plan file is empty")
+	}
+
+	var entries []models.PlanEntry
+	for _, t := range plan.Transitions {
+		entry := models.PlanEntry{Blueprint: t.Blueprint, OldDatasource: t.FromDatasource, NewDatasource: t.ToDatasource}
+		if len(t.Identifiers) == 0 {
+			entries = append(entries, entry)
+			continue
+		}
+		for _, id := range t.Identifiers {
+			entry.Identifier = id
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// readPlan reads a reviewed plan file written by writePlan, a JSON plan has to be of the operation
+func readPlan(path, operation string) ([]models.PlanEntry, error) {
+	if isJSONPlan(path) {
+		return readJSONPlan(path, operation)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plan file: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = len(planHeader)
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse plan file: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("plan file is empty")
+	}
+	for i, column := range planHeader {
+		if records[0][i] != column {
+			return nil, fmt.Errorf("invalid plan file header, expected %v", planHeader)
+		}
+	}
+
+	entries := make([]models.PlanEntry, 0, len(records)-1)
+	for _, record := range records[1:] {
+		entries = append(entries, models.PlanEntry{
+			Blueprint:     record[0],
+			Identifier:    record[1],
+			OldDatasource: record[2],
+			NewDatasource: record[3],
+		})
+	}
+
+	return entries, nil
+}
+
+// MigrateFromPlan patches exactly the identifiers listed in a reviewed plan file
+func (m *Migrator) MigrateFromPlan(planFile, newDatasourceID string, dryRun bool) (*models.MigrationStats, error) {
+	entries, err := readPlan(planFile, planOperationMigrate)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.migrateEntries(entries, "plan "+planFile, newDatasourceID, dryRun)
+}
+
+// migrateEntries patches the planned identifiers that still carry the old datasource.
+// An entry without an identifier stands for all of its blueprint's old entities.
+func (m *Migrator) migrateEntries(entries []models.PlanEntry, source, newDatasourceID string, dryRun bool) (*models.MigrationStats, error) {
+	stats := &models.MigrationStats{}
+
+	// Group identifiers per blueprint, keeping the plan's blueprint order
+	var blueprints []string
+	planned := make(map[string][]string)
+	wholeBlueprint := make(map[string]bool)
+	oldDatasources := make(map[string]map[string]string) // blueprint -> identifier -> old datasource
+	for _, entry := range entries {
+		if entry.NewDatasource != newDatasourceID {
+			return nil, fmt.Errorf("plan datasource drift for %s/%s: plan has %s but the resolved datasource is %s",
+				entry.Blueprint, entry.Identifier, entry.NewDatasource, newDatasourceID)
+		}
+		if _, seen := planned[entry.Blueprint]; !seen {
+			blueprints = append(blueprints, entry.Blueprint)
+			planned[entry.Blueprint] = nil
+		}
+		if entry.Identifier == "" {
+			wholeBlueprint[entry.Blueprint] = true
+			continue
+		}
+		planned[entry.Blueprint] = append(planned[entry.Blueprint], entry.Identifier)
+		if oldDatasources[entry.Blueprint] == nil {
+			oldDatasources[entry.Blueprint] = make(map[string]string)
+		}
+		oldDatasources[entry.Blueprint][entry.Identifier] = entry.OldDatasource
+	}
+
+	var manifest *manifestWriter
+	if m.config.ManifestFile != "" && !dryRun {
+		previous, err := readManifest(m.config.ManifestFile, m.log)
+		if err != nil {
+			return nil, err
+		}
+		remaining := m.skipCompleted(blueprints, completedBlueprints(previous))
+		recordSkipped(stats, skipManifest, len(blueprints)-len(remaining), 0)
+		blueprints = remaining
+
+		manifest, err = openManifest(m.config.ManifestFile)
+		if err != nil {
+			return nil, err
+		}
+		defer manifest.close()
+	}
+
+	stats.TotalBlueprints = len(blueprints)
+
+	if !m.config.NoWarning {
+		fmt.Fprintln(m.log)
+		fmt.Fprintln(m.log, "⚠️  WARNING: This action cannot be undone!")
+		fmt.Fprintf(m.log, "    Executing %s\n", source)
+		fmt.Fprintln(m.log)
+	}
+
+	// Only patch identifiers that still carry the old datasource
+	width := textwidth.NameColumn(blueprints)
+	totalEntities := 0
+	verified := make(map[string][]string)
+	searched := make(map[string]map[string]port.Entity) // blueprint -> identifier -> entity before the patch
+	for _, bp := range blueprints {
+		entities, err := m.searchOldEntitiesSettled(bp, stats)
+		if port.IsNotFound(err) {
+			fmt.Fprintf(m.log, "⚠️  Blueprint %s no longer exists, skipping %d planned entities\n", bp, len(planned[bp]))
+			stats.StaleBlueprints = append(stats.StaleBlueprints, bp)
+			recordSkipped(stats, skipStale, 1, len(planned[bp]))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to search entities for blueprint %s: %w", bp, err)
+		}
+
+		listed := make(map[string]bool)
+		for _, id := range planned[bp] {
+			listed[id] = true
+		}
+		current := make(map[string]port.Entity)
+		for _, entity := range entities {
+			current[entity.Identifier] = entity
+			if wholeBlueprint[bp] && !listed[entity.Identifier] {
+				planned[bp] = append(planned[bp], entity.Identifier)
+			}
+		}
+
+		searched[bp] = current
+
+		for _, id := range planned[bp] {
+			entity, ok := current[id]
+			if !ok {
+				fmt.Fprintf(m.log, "⚠️  %s/%s no longer has the old datasource, skipping\n", bp, id)
+				recordSkipped(stats, skipNotOld, 0, 1)
+				continue
+			}
+			// The plan was reviewed against that datasource, don't patch an entity that moved since
+			if reviewed := oldDatasources[bp][id]; reviewed != "" && entity.Datasource != reviewed {
+				fmt.Fprintf(m.log, "⚠️  %s/%s is on %s but the plan has %s, skipping\n", bp, id, entity.Datasource, reviewed)
+				recordSkipped(stats, skipChanged, 0, 1)
+				continue
+			}
+			verified[bp] = append(verified[bp], id)
+		}
+
+		fmt.Fprintf(m.out, "%s %d/%d\n", textwidth.Pad(bp, width), len(verified[bp]), len(planned[bp]))
+		totalEntities += len(verified[bp])
+	}
+
+	stats.TotalEntities = totalEntities
+	fmt.Fprintf(m.log, "\n📊 Total entities affected: %d\n", totalEntities)
+
+	if totalEntities == 0 {
+		fmt.Fprintln(m.log, "⚠️  No entities found to migrate. Exiting.")
+		m.printSkipped(stats)
+		return stats, nil
+	}
+
+	if dryRun {
+		fmt.Fprintln(m.log, "🔄 DRY RUN MODE - No changes will be made")
+		m.printSkipped(stats)
+		return stats, nil
+	}
+
+	if !m.confirmMigration(totalEntities) {
+		fmt.Fprintln(m.log, "❌ Migration cancelled.")
+		return stats, nil
+	}
+	m.emitStarted(newDatasourceID, blueprints, totalEntities)
+	m.progress = newProgress(totalEntities)
+
+	for i, bp := range blueprints {
+		identifiers := verified[bp]
+		if len(identifiers) == 0 {
+			continue
+		}
+		if err := m.checkStop(stats); err != nil {
+			for _, rest := range blueprints[i:] {
+				if len(verified[rest]) > 0 {
+					stats.Failures = append(stats.Failures, models.FailedBatch{Blueprint: rest, Identifiers: verified[rest], NewDatasource: newDatasourceID, Error: err.Error()})
+				}
+			}
+			return stats, err
+		}
+
+		fmt.Fprintf(m.log, "\n🔄 Migrating %d entities from blueprint: %s\n", len(identifiers), bp)
+		confirmed, err := m.patchIdentifiers(bp, identifiers, newDatasourceID, stats)
+		recordPatched(bp, len(confirmed), stats)
+		m.emitBlueprintDone(bp, newDatasourceID, len(confirmed), err)
+		patched := make([]port.Entity, len(confirmed))
+		for i, id := range confirmed {
+			patched[i] = searched[bp][id]
+		}
+		if manifest != nil && len(confirmed) > 0 {
+			if werr := manifest.write(m.newManifestEntry(bp, newDatasourceID, patched, err == nil)); werr != nil {
+				return stats, werr
+			}
+		}
+		if err != nil {
+			stats.FailedBatches++
+			stats.Errors = append(stats.Errors, fmt.Sprintf("Failed to migrate blueprint %s: %v", bp, err))
+			continue
+		}
+		if m.config.SummaryOnly {
+			fmt.Fprintf(m.log, "✅ Patched %d entities of %s\n", len(confirmed), bp)
+		}
+
+		if m.config.Verify {
+			m.verifyBlueprint(bp, confirmed, newDatasourceID, stats)
+		}
+		if m.config.VerifyIntegrity {
+			m.verifyIntegrity(bp, patched, stats)
+		}
+
+		stats.SuccessfulBatches++
+	}
+
+	if stats.DeadlineExceeded {
+		// The deadline passed in the last blueprint, there was no next one to stop before
+		return stats, m.checkDeadline(stats)
+	}
+
+	fmt.Fprintln(m.out)
+	fmt.Fprintf(m.out, "✅ Migration complete! Successfully migrated %d blueprints\n", stats.SuccessfulBatches)
+	m.printSkipped(stats)
+
+	return stats, nil
+}
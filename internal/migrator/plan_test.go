@@ -0,0 +1,37 @@
+package migrator
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+func TestMigrateFromPlanAlignment(t *testing.T) {
+	long := "githubRepositoryDeploymentEnvironmentProtectionRule"
+	srv, newDatasource := newFixture(t, 2, long, "服务目录")
+
+	entries := []models.PlanEntry{
+		{Blueprint: long, NewDatasource: newDatasource},
+		{Blueprint: "服务目录", NewDatasource: newDatasource},
+	}
+	plan := filepath.Join(t.TempDir(), "plan.json")
+	if err := writePlan(plan, planOperationMigrate, entries); err != nil {
+		t.Fatalf("writePlan() failed: %v", err)
+	}
+
+	m, _ := newTestMigrator(srv.Client(), &models.Config{})
+	var out bytes.Buffer
+	m.SetOutput(&out, &bytes.Buffer{})
+	if _, err := m.MigrateFromPlan(plan, newDatasource, true); err != nil {
+		t.Fatalf("MigrateFromPlan() failed: %v", err)
+	}
+
+	want := long + " 2/2\n" +
+		"服务目录                                            2/2\n"
+	if !strings.HasPrefix(out.String(), want) {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,64 @@
+package migrator
+
+import (
+	"sort"
+
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+// BlueprintPreview is a blueprint to migrate as listed before migrating all blueprints
+type BlueprintPreview struct {
+	Blueprint  string
+	Count      int  // old entities, -1 when they couldn't be searched
+	Uningested bool // nothing on the new datasource yet, skipped unless --allow-uningested
+}
+
+// Preview lists the blueprints Migrate would go through with all blueprints, largest first, after
+// --resume-from-blueprint and the manifest left out theirs. The blueprints are searched the way Migrate
+// counts them, with up to --count-concurrency at once, and Migrate reuses the list and the searches.
+// Blueprints that no longer exist or have no old entities are left out.
+func (m *Migrator) Preview(dryRun bool) ([]BlueprintPreview, error) {
+	scope, err := m.resolveScope(nil, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	m.scoped = scope
+	blueprints := scope.blueprints
+
+	useCachedCounts := m.useCachedCounts(nil)
+	fetched := m.fetchBlueprints(blueprints, useCachedCounts)
+
+	m.previewed = make(map[string]blueprintFetch, len(blueprints))
+	var rows []BlueprintPreview
+	for i, bp := range blueprints {
+		f := fetched[i]
+		m.previewed[bp] = f
+
+		count := m.config.BlueprintCounts[bp]
+		if !useCachedCounts {
+			if port.IsNotFound(f.err) {
+				// Stale data-sources entry, Migrate warns about it
+				continue
+			}
+			count = len(f.entities)
+			if f.err != nil {
+				count = -1
+			}
+		}
+		if count == 0 {
+			continue
+		}
+
+		rows = append(rows, BlueprintPreview{
+			Blueprint:  bp,
+			Count:      count,
+			Uningested: count > 0 && f.newErr == nil && f.newCount == 0,
+		})
+	}
+
+	// Largest blueprints first so a limited preview shows the ones that matter
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Count > rows[j].Count
+	})
+	return rows, nil
+}
@@ -0,0 +1,98 @@
+package migrator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+func TestPreview(t *testing.T) {
+	srv, newDatasource := newFixture(t, 3, "githubRepository")
+	addBlueprint(srv, "githubPullRequest", 5, true)
+	addBlueprint(srv, "githubIssue", 2, false) // not ingested by the new integration yet
+	addBlueprint(srv, "githubTeam", 0, true)   // nothing to migrate
+	srv.AddDataSource(oldInstallID, "githubPullRequest", "githubIssue", "githubTeam", "deletedBlueprint")
+
+	m, _ := newTestMigrator(srv.Client(), &models.Config{CountConcurrency: 4})
+	rows, err := m.Preview(true)
+	if err != nil {
+		t.Fatalf("Preview() failed: %v", err)
+	}
+
+	want := "[{githubPullRequest 5 false} {githubRepository 3 false} {githubIssue 2 true}]"
+	if got := fmt.Sprint(rows); got != want {
+		t.Errorf("Preview() = %s, want %s", got, want)
+	}
+
+	// Migrate counts from the preview's searches instead of repeating them
+	if _, err := m.Migrate(newDatasource, nil, true); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+	for _, bp := range []string{"githubRepository", "githubPullRequest", "githubIssue"} {
+		if got := srv.Requests("POST /v1/blueprints/" + bp + "/entities/search"); got != 1 {
+			t.Errorf("%s searched %d times, want once", bp, got)
+		}
+	}
+}
+
+func TestPreviewCachedCounts(t *testing.T) {
+	srv, _ := newFixture(t, 3, "githubRepository")
+
+	counts := map[string]int{"githubRepository": 3, "githubPullRequest": 7, "githubTeam": 0}
+	addBlueprint(srv, "githubPullRequest", 7, false)
+	m, _ := newTestMigrator(srv.Client(), &models.Config{BlueprintCounts: counts})
+	rows, err := m.Preview(false)
+	if err != nil {
+		t.Fatalf("Preview() failed: %v", err)
+	}
+
+	want := "[{githubPullRequest 7 true} {githubRepository 3 false}]"
+	if got := fmt.Sprint(rows); got != want {
+		t.Errorf("Preview() = %s, want %s", got, want)
+	}
+	if got := srv.Requests("POST /v1/blueprints/githubRepository/entities/search"); got != 0 {
+		t.Errorf("searched %d times with cached counts, want none", got)
+	}
+}
+
+func TestPreviewLeavesOutSkippedBlueprints(t *testing.T) {
+	srv, newDatasource := newFixture(t, 3, "githubIssue", "githubPullRequest", "githubRepository")
+	manifest := filepath.Join(t.TempDir(), "manifest.jsonl")
+
+	// githubPullRequest is already done according to the manifest
+	m, _ := newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest})
+	if _, err := m.Migrate(newDatasource, []string{"githubPullRequest"}, false); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	m, log := newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest, ResumeFromBlueprint: "githubPullRequest"})
+	rows, err := m.Preview(false)
+	if err != nil {
+		t.Fatalf("Preview() failed: %v", err)
+	}
+	if got, want := fmt.Sprint(rows), "[{githubRepository 3 false}]"; got != want {
+		t.Errorf("Preview() = %s, want %s", got, want)
+	}
+
+	// Migrate goes through the previewed blueprints without resolving them again
+	stats, err := m.Migrate(newDatasource, nil, false)
+	if err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+	want := map[string]models.SkipCount{skipResumeFrom: {Blueprints: 1}, skipManifest: {Blueprints: 1}}
+	if fmt.Sprint(stats.Skipped) != fmt.Sprint(want) {
+		t.Errorf("skipped = %v, want %v", stats.Skipped, want)
+	}
+	if n := strings.Count(log.String(), "Resuming from githubPullRequest"); n != 1 {
+		t.Errorf("resuming reported %d times, want once:\n%s", n, log)
+	}
+	if n := oldEntities(srv, "githubIssue"); n != 3 {
+		t.Errorf("%d githubIssue entities left on the old datasource, want 3", n)
+	}
+	if n := oldEntities(srv, "githubRepository"); n != 0 {
+		t.Errorf("%d githubRepository entities left on the old datasource, want 0", n)
+	}
+}
@@ -0,0 +1,55 @@
+package migrator
+
+import (
+	"fmt"
+	"time"
+)
+
+// progress tracks how many of a migration's entities were processed, for the percentage and the
+// ETA of the progress lines
+type progress struct {
+	total   int
+	done    int
+	started time.Time
+}
+
+// newProgress starts tracking a migration of total entities
+func newProgress(total int) *progress {
+	return &progress{total: total, started: time.Now()}
+}
+
+// add counts processed entities, patched or failed
+func (p *progress) add(n int) {
+	if p == nil {
+		return
+	}
+	p.done += n
+}
+
+// eta estimates the time left at the rate entities were processed so far, false until there is a rate
+func (p *progress) eta() (time.Duration, bool) {
+	elapsed := time.Since(p.started)
+	if p.done == 0 || elapsed <= 0 {
+		return 0, false
+	}
+	remaining := p.total - p.done
+	if remaining <= 0 {
+		return 0, true
+	}
+	rate := float64(p.done) / elapsed.Seconds()
+	return time.Duration(float64(remaining) / rate * float64(time.Second)), true
+}
+
+// String returns the percentage done and the ETA, e.g. "42% (420/1000), ETA ~3m20s"
+func (p *progress) String() string {
+	done := min(p.done, p.total)
+	percent := 100
+	if p.total > 0 {
+		percent = done * 100 / p.total
+	}
+	line := fmt.Sprintf("%d%% (%d/%d)", percent, done, p.total)
+	if eta, ok := p.eta(); ok && done < p.total {
+		line += fmt.Sprintf(", ETA ~%s", eta.Round(time.Second))
+	}
+	return line
+}
@@ -0,0 +1,40 @@
+package migrator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/textwidth"
+)
+
+// projectCounts projects a blueprint's counts after migrating the given number of its old entities,
+// false when its new entities couldn't be counted
+func projectCounts(oldNow, migrating int, f blueprintFetch) (models.DatasourceProjection, bool) {
+	if f.newErr != nil {
+		return models.DatasourceProjection{}, false
+	}
+	return models.DatasourceProjection{
+		OldNow:   oldNow,
+		NewNow:   f.newCount,
+		OldAfter: oldNow - migrating,
+		NewAfter: f.newCount + migrating,
+	}, true
+}
+
+// printProjection prints the dry run's before and after counts of each blueprint on the old and new datasource
+func (m *Migrator) printProjection(blueprints []string, projections map[string]models.DatasourceProjection) {
+	width := textwidth.NameColumn(blueprints)
+
+	fmt.Fprintln(m.log)
+	header := fmt.Sprintf("%s %-10s %-10s %-10s %s", textwidth.Pad("BLUEPRINT", width), "OLD NOW", "OLD AFTER", "NEW NOW", "NEW AFTER")
+	fmt.Fprintln(m.log, header)
+	fmt.Fprintln(m.log, strings.Repeat("─", textwidth.Width(header)))
+	for _, bp := range blueprints {
+		p, ok := projections[bp]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(m.log, "%s %-10d %-10d %-10d %d\n", textwidth.Pad(bp, width), p.OldNow, p.OldAfter, p.NewNow, p.NewAfter)
+	}
+}
@@ -0,0 +1,34 @@
+package migrator
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+func TestPrintProjectionAlignment(t *testing.T) {
+	srv, _ := newFixture(t, 0)
+	m, _ := newTestMigrator(srv.Client(), &models.Config{})
+	var log bytes.Buffer
+	m.SetOutput(io.Discard, &log)
+
+	blueprints := []string{"githubRepositoryDeploymentEnvironmentProtectionRule", "服务目录", "café"}
+	m.printProjection(blueprints, map[string]models.DatasourceProjection{
+		"githubRepositoryDeploymentEnvironmentProtectionRule": {OldNow: 3, NewNow: 1, OldAfter: 0, NewAfter: 4},
+		"服务目录":  {OldNow: 2, NewNow: 0, OldAfter: 0, NewAfter: 2},
+		"café": {OldNow: 1, NewNow: 1, OldAfter: 1, NewAfter: 1},
+	})
+
+	want := "\n" +
+		"BLUEPRINT                                           OLD NOW    OLD AFTER  NEW NOW    NEW AFTER\n" +
+		strings.Repeat("─", 94) + "\n" +
+		"githubRepositoryDeploymentEnvironmentProtectionRule 3          0          1          4\n" +
+		"服务目录                                            2          0          0          2\n" +
+		"café                                                1          1          1          1\n"
+	if log.String() != want {
+		t.Errorf("projection:\n%s\nwant:\n%s", log.String(), want)
+	}
+}
@@ -0,0 +1,328 @@
+package migrator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/omby8888/port-github-migrator/internal/confirm"
+	"github.com/omby8888/port-github-migrator/internal/jsonout"
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+// rollbackPreviewLimit is how many identifiers of a blueprint the rollback dry run lists
+const rollbackPreviewLimit = 10
+
+// RollbackBlueprints returns the blueprints a rollback covers: those recorded in the manifest
+// when there is one, the old installation's otherwise
+func (m *Migrator) RollbackBlueprints() ([]string, error) {
+	if m.config.ManifestFile == "" {
+		blueprints, err := m.client.GetBlueprintsByDataSource(m.config.OldInstallationID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get blueprints: %w", err)
+		}
+		sort.Strings(blueprints)
+		return blueprints, nil
+	}
+
+	entries, err := readManifest(m.config.ManifestFile, m.log)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var blueprints []string
+	for _, entry := range entries {
+		if !seen[entry.Blueprint] {
+			seen[entry.Blueprint] = true
+			blueprints = append(blueprints, entry.Blueprint)
+		}
+	}
+	sort.Strings(blueprints)
+	return blueprints, nil
+}
+
+// RollbackDryRun shows which entities on the new datasource a rollback would re-point to the old
+// datasource, without patching anything. With --manifest-file only the entities the migration patched
+// are candidates and each gets back the datasource recorded for it, otherwise every entity on the
+// new datasource would get the old installation's datasource.
+func (m *Migrator) RollbackDryRun(blueprints []string) (*models.RollbackSummary, error) {
+	fmt.Fprintln(m.log, "🔄 DRY RUN MODE - No changes will be made")
+
+	summary, planEntries, err := m.rollbackCandidates(blueprints)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.config.PlanFile != "" {
+		if err := writePlan(m.config.PlanFile, planOperationRollback, planEntries); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(m.log, "📝 Wrote %d planned changes to %s\n", len(planEntries), m.config.PlanFile)
+	}
+
+	if m.config.Output == "json" {
+		encoder := jsonout.NewEncoder(m.out, m.config.JSONCompact)
+		return summary, encoder.Encode(summary)
+	}
+
+	m.printRollback(blueprints, summary)
+	return summary, nil
+}
+
+// Rollback re-points the entities RollbackDryRun lists back to their old datasource, once the old
+// installation ID is typed to confirm. Each blueprint's entities are patched in batches per datasource.
+func (m *Migrator) Rollback(blueprints []string) (*models.MigrationStats, error) {
+	summary, _, err := m.rollbackCandidates(blueprints)
+	if err != nil {
+		return nil, err
+	}
+	return m.rollBack(blueprints, summary)
+}
+
+// RollbackFromPlan re-points exactly the entities listed in a reviewed rollback plan, those still on
+// the datasource the plan moves them from. With dryRun it only lists them.
+func (m *Migrator) RollbackFromPlan(planFile string, dryRun bool) (*models.MigrationStats, error) {
+	entries, err := readPlan(planFile, planOperationRollback)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		fmt.Fprintln(m.log, "🔄 DRY RUN MODE - No changes will be made")
+	}
+
+	// Group the entries per blueprint, keeping the plan's blueprint order
+	var blueprints []string
+	planned := make(map[string][]models.PlanEntry)
+	for _, entry := range entries {
+		// A CSV plan has no operation, but only a rollback plan moves entities to the old installation
+		if entry.Identifier == "" || !strings.HasPrefix(entry.NewDatasource, port.OldDatasourceKind) || !strings.Contains(entry.NewDatasource, m.config.OldInstallationID) {
+			return nil, fmt.Errorf("plan entry %s/%s doesn't roll back to the old installation %s, was the plan written by rollback --dry-run?",
+				entry.Blueprint, entry.Identifier, m.config.OldInstallationID)
+		}
+		if _, seen := planned[entry.Blueprint]; !seen {
+			blueprints = append(blueprints, entry.Blueprint)
+		}
+		planned[entry.Blueprint] = append(planned[entry.Blueprint], entry)
+	}
+
+	summary := &models.RollbackSummary{
+		Blueprints: make(map[string]int),
+		Entities:   make(map[string][]models.ManifestEntity),
+	}
+	for _, bp := range blueprints {
+		entities, err := m.client.SearchNewEntitiesByBlueprint(bp, m.config.NewInstallationID)
+		if port.IsNotFound(err) {
+			fmt.Fprintf(m.log, "⚠️  Blueprint %s no longer exists, skipping %d planned entities\n", bp, len(planned[bp]))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to search new entities for blueprint %s: %w", bp, err)
+		}
+
+		current := make(map[string]string) // identifier -> datasource on the new installation
+		for _, e := range entities {
+			current[e.Identifier] = e.Datasource
+		}
+		var candidates []models.ManifestEntity
+		for _, entry := range planned[bp] {
+			if current[entry.Identifier] != entry.OldDatasource {
+				fmt.Fprintf(m.log, "⚠️  %s/%s is no longer on %s, skipping\n", bp, entry.Identifier, entry.OldDatasource)
+				continue
+			}
+			candidates = append(candidates, models.ManifestEntity{Identifier: entry.Identifier, OldDatasource: entry.NewDatasource})
+		}
+
+		summary.Blueprints[bp] = len(candidates)
+		summary.TotalEntities += len(candidates)
+		if len(candidates) > 0 {
+			summary.Entities[bp] = candidates
+		}
+	}
+
+	if dryRun {
+		stats := &models.MigrationStats{TotalBlueprints: len(summary.Entities), TotalEntities: summary.TotalEntities}
+		if m.config.Output == "json" {
+			encoder := jsonout.NewEncoder(m.out, m.config.JSONCompact)
+			return stats, encoder.Encode(summary)
+		}
+		m.printRollback(blueprints, summary)
+		return stats, nil
+	}
+	return m.rollBack(blueprints, summary)
+}
+
+// rollBack lists the summary's entities and, once the old installation ID is typed to confirm,
+// patches them in batches per datasource
+func (m *Migrator) rollBack(blueprints []string, summary *models.RollbackSummary) (*models.MigrationStats, error) {
+	m.printRollback(blueprints, summary)
+
+	stats := &models.MigrationStats{TotalBlueprints: len(summary.Entities), TotalEntities: summary.TotalEntities}
+	if summary.TotalEntities == 0 {
+		fmt.Fprintln(m.log, "⚠️  No entities found to roll back. Exiting.")
+		return stats, nil
+	}
+
+	// Stricter than migrate's 'yes', a rollback undoes the whole migration
+	prompt := confirm.Prompt{
+		Text:    fmt.Sprintf("Type the old installation ID (%s) to roll back %d entities", m.config.OldInstallationID, summary.TotalEntities),
+		Phrase:  m.config.OldInstallationID,
+		Timeout: m.config.ConfirmTimeout,
+	}
+	if !prompt.Ask(m.in, m.log) {
+		fmt.Fprintln(m.log, "❌ Rollback cancelled.")
+		return stats, nil
+	}
+	m.progress = newProgress(summary.TotalEntities)
+
+	for _, bp := range blueprints {
+		candidates := summary.Entities[bp]
+		if len(candidates) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(m.log, "\n🔄 Rolling back %d entities of blueprint: %s\n", len(candidates), bp)
+		byDatasource := make(map[string][]string)
+		for _, c := range candidates {
+			byDatasource[c.OldDatasource] = append(byDatasource[c.OldDatasource], c.Identifier)
+		}
+		datasources := make([]string, 0, len(byDatasource))
+		for ds := range byDatasource {
+			datasources = append(datasources, ds)
+		}
+		sort.Strings(datasources)
+
+		rolledBack := 0
+		var failed error
+		for _, ds := range datasources {
+			confirmed, err := m.patchIdentifiers(bp, byDatasource[ds], ds, stats)
+			rolledBack += len(confirmed)
+			if err != nil {
+				failed = err
+				break
+			}
+		}
+		recordPatched(bp, rolledBack, stats)
+		if failed != nil {
+			stats.FailedBatches++
+			stats.Errors = append(stats.Errors, fmt.Sprintf("Failed to roll back blueprint %s: %v", bp, failed))
+			continue
+		}
+		stats.SuccessfulBatches++
+	}
+
+	fmt.Fprintln(m.out)
+	fmt.Fprintf(m.out, "✅ Rollback complete! Rolled back %d blueprints, %d failed\n", stats.SuccessfulBatches, stats.FailedBatches)
+	return stats, nil
+}
+
+// rollbackCandidates searches the blueprints' entities on the new datasource for those a rollback
+// re-points, with the datasource each goes back to
+func (m *Migrator) rollbackCandidates(blueprints []string) (*models.RollbackSummary, []models.PlanEntry, error) {
+	var recorded map[string]map[string]string // blueprint -> identifier -> old datasource
+	if m.config.ManifestFile != "" {
+		entries, err := readManifest(m.config.ManifestFile, m.log)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(entries) == 0 {
+			return nil, nil, fmt.Errorf("manifest %s has no migrated entities to roll back", m.config.ManifestFile)
+		}
+		recorded = make(map[string]map[string]string)
+		for _, entry := range entries {
+			if recorded[entry.Blueprint] == nil {
+				recorded[entry.Blueprint] = make(map[string]string)
+			}
+			for _, e := range entry.Entities {
+				recorded[entry.Blueprint][e.Identifier] = e.OldDatasource
+			}
+		}
+	} else {
+		fmt.Fprintln(m.log, "⚠️  Without --manifest-file, entities the new integration created itself would be rolled back too")
+	}
+
+	summary := &models.RollbackSummary{
+		Blueprints: make(map[string]int),
+		Entities:   make(map[string][]models.ManifestEntity),
+	}
+	oldDatasource := port.OldDatasource(m.config.OldInstallationID)
+	var planEntries []models.PlanEntry
+	for _, bp := range blueprints {
+		entities, err := m.client.SearchNewEntitiesByBlueprint(bp, m.config.NewInstallationID)
+		if port.IsNotFound(err) {
+			fmt.Fprintf(m.log, "⚠️  Blueprint %s no longer exists, skipping\n", bp)
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to search new entities for blueprint %s: %w", bp, err)
+		}
+
+		var candidates []models.ManifestEntity
+		current := make(map[string]string) // identifier -> datasource the rollback moves it from
+		for _, e := range entities {
+			current[e.Identifier] = e.Datasource
+			target := oldDatasource
+			if recorded != nil {
+				previous, ok := recorded[bp][e.Identifier]
+				if !ok {
+					continue
+				}
+				target = previous
+			}
+			candidates = append(candidates, models.ManifestEntity{Identifier: e.Identifier, OldDatasource: target})
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].Identifier < candidates[j].Identifier
+		})
+		for _, c := range candidates {
+			planEntries = append(planEntries, models.PlanEntry{
+				Blueprint:     bp,
+				Identifier:    c.Identifier,
+				OldDatasource: current[c.Identifier],
+				NewDatasource: c.OldDatasource,
+			})
+		}
+
+		summary.Blueprints[bp] = len(candidates)
+		summary.TotalEntities += len(candidates)
+		if len(candidates) > 0 {
+			summary.Entities[bp] = candidates
+		}
+	}
+
+	return summary, planEntries, nil
+}
+
+// printRollback lists per blueprint the datasources the entities go back to and the first identifiers
+func (m *Migrator) printRollback(blueprints []string, summary *models.RollbackSummary) {
+	for _, bp := range blueprints {
+		candidates := summary.Entities[bp]
+		if len(candidates) == 0 {
+			continue
+		}
+
+		targets := make(map[string]int)
+		for _, c := range candidates {
+			targets[c.OldDatasource]++
+		}
+		fmt.Fprintf(m.out, "\n🔄 Would roll back %d entities of blueprint: %s\n", len(candidates), bp)
+		datasources := make([]string, 0, len(targets))
+		for ds := range targets {
+			datasources = append(datasources, ds)
+		}
+		sort.Strings(datasources)
+		for _, target := range datasources {
+			fmt.Fprintf(m.out, "   → %s (%d)\n", target, targets[target])
+		}
+		for i, c := range candidates {
+			if i == rollbackPreviewLimit {
+				fmt.Fprintf(m.out, "       ... and %d more\n", len(candidates)-rollbackPreviewLimit)
+				break
+			}
+			fmt.Fprintf(m.out, "       • %s\n", c.Identifier)
+		}
+	}
+
+	fmt.Fprintln(m.out)
+	fmt.Fprintf(m.out, "📊 Total entities that would be rolled back: %d\n", summary.TotalEntities)
+}
@@ -0,0 +1,124 @@
+package migrator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+func TestRollback(t *testing.T) {
+	tests := []struct {
+		name           string
+		answer         string
+		wantRolledBack bool
+	}{
+		{"installation ID typed", oldInstallID + "\n", true},
+		{"migrate's phrase isn't enough", "yes\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, newDatasource := newFixture(t, 120, "githubRepository")
+			manifest := filepath.Join(t.TempDir(), "manifest.jsonl")
+
+			m, _ := newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest})
+			if _, err := m.Migrate(newDatasource, nil, false); err != nil {
+				t.Fatalf("Migrate() failed: %v", err)
+			}
+			if n := oldEntities(srv, "githubRepository"); n != 0 {
+				t.Fatalf("%d entities left on the old datasource after migrating", n)
+			}
+
+			r, log := newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest})
+			r.SetInput(strings.NewReader(tt.answer))
+			stats, err := r.Rollback([]string{"githubRepository"})
+			if err != nil {
+				t.Fatalf("Rollback() failed: %v", err)
+			}
+			if !strings.Contains(log.String(), "Type the old installation ID ("+oldInstallID+") to roll back 120 entities") {
+				t.Errorf("the prompt didn't ask for the installation ID:\n%s", log)
+			}
+
+			want := 0
+			if tt.wantRolledBack {
+				want = 120
+			}
+			if n := oldEntities(srv, "githubRepository"); n != want {
+				t.Errorf("%d entities back on the old datasource, want %d", n, want)
+			}
+			if stats.PatchedEntities["githubRepository"] != want {
+				t.Errorf("stats count %d rolled back entities, want %d", stats.PatchedEntities["githubRepository"], want)
+			}
+			// The entity the new integration created itself isn't in the manifest and stays
+			if n := len(srv.Entities("githubRepository")) - oldEntities(srv, "githubRepository"); n != 121-want {
+				t.Errorf("%d entities on the new datasource, want %d", n, 121-want)
+			}
+		})
+	}
+}
+
+func TestRollbackFromPlan(t *testing.T) {
+	for _, name := range []string{"plan.json", "plan.csv"} {
+		t.Run(name, func(t *testing.T) {
+			srv, newDatasource := newFixture(t, 5, "githubRepository")
+			dir := t.TempDir()
+			manifest := filepath.Join(dir, "manifest.jsonl")
+			plan := filepath.Join(dir, name)
+
+			m, _ := newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest})
+			if _, err := m.Migrate(newDatasource, nil, false); err != nil {
+				t.Fatalf("Migrate() failed: %v", err)
+			}
+			r, _ := newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest, PlanFile: plan})
+			if _, err := r.RollbackDryRun([]string{"githubRepository"}); err != nil {
+				t.Fatalf("RollbackDryRun() failed: %v", err)
+			}
+
+			// A rollback plan can't be executed as a migration
+			if _, err := m.MigrateFromPlan(plan, newDatasource, true); err == nil {
+				t.Error("MigrateFromPlan() accepted a rollback plan")
+			}
+
+			// Rolled back by hand since the plan was reviewed
+			if _, err := srv.Client().PatchEntitiesDatasourceBulk("githubRepository", []string{"githubRepository-0"}, port.OldDatasource(oldInstallID)); err != nil {
+				t.Fatal(err)
+			}
+
+			r, log := newTestMigrator(srv.Client(), &models.Config{})
+			r.SetInput(strings.NewReader(oldInstallID + "\n"))
+			stats, err := r.RollbackFromPlan(plan, false)
+			if err != nil {
+				t.Fatalf("RollbackFromPlan() failed: %v", err)
+			}
+			if !strings.Contains(log.String(), "githubRepository/githubRepository-0 is no longer on "+newDatasource) {
+				t.Errorf("the entity rolled back since wasn't reported:\n%s", log)
+			}
+			if stats.PatchedEntities["githubRepository"] != 4 {
+				t.Errorf("rolled back %d entities, want the 4 still on the new datasource", stats.PatchedEntities["githubRepository"])
+			}
+			// The entity the new integration created itself isn't in the plan and stays
+			if n := oldEntities(srv, "githubRepository"); n != 5 {
+				t.Errorf("%d entities back on the old datasource, want 5", n)
+			}
+		})
+	}
+}
+
+func TestRollbackFromPlanRefusesMigratePlan(t *testing.T) {
+	srv, newDatasource := newFixture(t, 2, "githubRepository")
+	plan := filepath.Join(t.TempDir(), "plan.csv")
+	m, _ := newTestMigrator(srv.Client(), &models.Config{PlanFile: plan})
+	if _, err := m.Migrate(newDatasource, nil, true); err != nil {
+		t.Fatalf("Migrate() dry run failed: %v", err)
+	}
+
+	r, _ := newTestMigrator(srv.Client(), &models.Config{})
+	if _, err := r.RollbackFromPlan(plan, false); err == nil || !strings.Contains(err.Error(), "doesn't roll back to the old installation") {
+		t.Errorf("RollbackFromPlan() = %v, want the migrate plan refused", err)
+	}
+	if n := oldEntities(srv, "githubRepository"); n != 2 {
+		t.Errorf("%d entities left on the old datasource, want 2", n)
+	}
+}
@@ -0,0 +1,58 @@
+package migrator
+
+import (
+	"fmt"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+// Reasons blueprints or entities were left out of a migration's scope
+const (
+	skipResumeFrom = "resumeFrom" // sorted before --resume-from-blueprint
+	skipManifest   = "manifest"   // already migrated according to --manifest-file
+	skipStale      = "stale"      // listed in the data-sources but deleted since
+	skipUningested = "uningested" // nothing on the new datasource yet, see --allow-uningested
+	skipStrict     = "strict"     // datasource isn't exactly the old one, see --strict
+	skipNotOld     = "notOld"     // listed by --from-plan or --from-error-file but no longer on the old datasource
+	skipChanged    = "changed"    // on another old datasource than the one --from-plan recorded
+)
+
+// skipReasons is the order the reasons are printed in
+var skipReasons = []string{skipResumeFrom, skipManifest, skipStale, skipUningested, skipStrict, skipNotOld, skipChanged}
+
+// recordSkipped counts blueprints and entities a filter left out of the migration
+func recordSkipped(stats *models.MigrationStats, reason string, blueprints, entities int) {
+	if blueprints == 0 && entities == 0 {
+		return
+	}
+	if stats.Skipped == nil {
+		stats.Skipped = make(map[string]models.SkipCount)
+	}
+	count := stats.Skipped[reason]
+	count.Blueprints += blueprints
+	count.Entities += entities
+	stats.Skipped[reason] = count
+}
+
+// printSkipped prints what each filter left out, so the user can confirm the scope is what they intended
+func (m *Migrator) printSkipped(stats *models.MigrationStats) {
+	if len(stats.Skipped) == 0 {
+		return
+	}
+
+	fmt.Fprintln(m.out, "⏭️  Skipped:")
+	for _, reason := range skipReasons {
+		count, ok := stats.Skipped[reason]
+		if !ok {
+			continue
+		}
+		switch {
+		case count.Blueprints > 0 && count.Entities > 0:
+			fmt.Fprintf(m.out, "   %s: %d blueprints, %d entities\n", reason, count.Blueprints, count.Entities)
+		case count.Blueprints > 0:
+			fmt.Fprintf(m.out, "   %s: %d blueprints\n", reason, count.Blueprints)
+		default:
+			fmt.Fprintf(m.out, "   %s: %d entities\n", reason, count.Entities)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package migrator
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+func TestMigrateFromPlanCountsSkipped(t *testing.T) {
+	srv, newDatasource := newFixture(t, 5, "githubPullRequest", "githubRepository")
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.jsonl")
+
+	// githubPullRequest is already done according to the manifest
+	m, _ := newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest})
+	if _, err := m.Migrate(newDatasource, []string{"githubPullRequest"}, false); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	entries := []models.PlanEntry{{Blueprint: "githubPullRequest", NewDatasource: newDatasource}}
+	for i := 0; i < 3; i++ {
+		entries = append(entries, models.PlanEntry{Blueprint: "githubRepository", Identifier: fmt.Sprintf("githubRepository-%d", i), NewDatasource: newDatasource})
+	}
+	// Already on the new datasource, and a blueprint deleted since the plan was reviewed
+	entries = append(entries,
+		models.PlanEntry{Blueprint: "githubRepository", Identifier: "githubRepository-ingested", NewDatasource: newDatasource},
+		models.PlanEntry{Blueprint: "githubTeam", Identifier: "team-1", NewDatasource: newDatasource},
+		models.PlanEntry{Blueprint: "githubTeam", Identifier: "team-2", NewDatasource: newDatasource},
+	)
+	plan := filepath.Join(dir, "plan.json")
+	if err := writePlan(plan, planOperationMigrate, entries); err != nil {
+		t.Fatalf("writePlan() failed: %v", err)
+	}
+
+	m, _ = newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest})
+	var out bytes.Buffer
+	m.SetOutput(&out, &bytes.Buffer{})
+	stats, err := m.MigrateFromPlan(plan, newDatasource, false)
+	if err != nil {
+		t.Fatalf("MigrateFromPlan() failed: %v", err)
+	}
+
+	want := map[string]models.SkipCount{
+		skipManifest: {Blueprints: 1},
+		skipStale:    {Blueprints: 1, Entities: 2},
+		skipNotOld:   {Entities: 1},
+	}
+	if fmt.Sprint(stats.Skipped) != fmt.Sprint(want) {
+		t.Errorf("skipped = %v, want %v", stats.Skipped, want)
+	}
+	for _, line := range []string{"manifest: 1 blueprints", "stale: 1 blueprints, 2 entities", "notOld: 1 entities"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("summary doesn't contain %q:\n%s", line, out.String())
+		}
+	}
+	if n := oldEntities(srv, "githubRepository"); n != 2 {
+		t.Errorf("%d githubRepository entities left on the old datasource, want the 2 unplanned", n)
+	}
+	for _, p := range srv.Patches() {
+		if p.Blueprint == "githubRepository" && len(p.Identifiers) != 3 {
+			t.Errorf("patched %v, want only the 3 planned entities", p.Identifiers)
+		}
+	}
+}
+
+func TestMigrateFromPlanSkipsChangedDatasource(t *testing.T) {
+	srv, newDatasource := newFixture(t, 3, "githubRepository")
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.jsonl")
+
+	// githubRepository-2 was reviewed on another datasource of the old installation than it's on now
+	var entries []models.PlanEntry
+	for i := 0; i < 3; i++ {
+		entries = append(entries, models.PlanEntry{Blueprint: "githubRepository", Identifier: fmt.Sprintf("githubRepository-%d", i), OldDatasource: port.OldDatasource(oldInstallID), NewDatasource: newDatasource})
+	}
+	entries[2].OldDatasource += "/my-org"
+	plan := filepath.Join(dir, "plan.json")
+	if err := writePlan(plan, planOperationMigrate, entries); err != nil {
+		t.Fatalf("writePlan() failed: %v", err)
+	}
+
+	m, log := newTestMigrator(srv.Client(), &models.Config{ManifestFile: manifest})
+	stats, err := m.MigrateFromPlan(plan, newDatasource, false)
+	if err != nil {
+		t.Fatalf("MigrateFromPlan() failed: %v", err)
+	}
+
+	if want := map[string]models.SkipCount{skipChanged: {Entities: 1}}; fmt.Sprint(stats.Skipped) != fmt.Sprint(want) {
+		t.Errorf("skipped = %v, want %v", stats.Skipped, want)
+	}
+	if !strings.Contains(log.String(), "githubRepository/githubRepository-2 is on "+port.OldDatasource(oldInstallID)) {
+		t.Errorf("the changed datasource wasn't reported:\n%s", log.String())
+	}
+	if n := oldEntities(srv, "githubRepository"); n != 1 {
+		t.Errorf("%d githubRepository entities left on the old datasource, want the changed one", n)
+	}
+
+	// The manifest keeps the datasource read from Port for rollback
+	written, err := readManifest(manifest, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("readManifest() failed: %v", err)
+	}
+	for _, entry := range written {
+		for _, e := range entry.Entities {
+			if e.OldDatasource != port.OldDatasource(oldInstallID) {
+				t.Errorf("manifest has %s on %s, want %s", e.Identifier, e.OldDatasource, port.OldDatasource(oldInstallID))
+			}
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"time"
+
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
 // Config holds migration configuration
 type Config struct {
 	PortAPIURL          string
@@ -7,41 +13,187 @@ type Config struct {
 	ClientSecret        string
 	OldInstallationID   string
 	NewInstallationID   string
+	PlanFile            string
+	Verify              bool
+	ResumeFromBlueprint string
+	BlueprintCounts     map[string]int // cached blueprint entity counts, skips discovery when set
+	Output              string         // "table" or "json", json emits the dry run plan without prompting
+	BlueprintRetries    int            // extra attempts for a blueprint that failed to migrate
+	BlueprintRetryDelay time.Duration  // wait before re-attempting a failed blueprint
+	Strict              bool           // only migrate entities whose datasource is exactly the old one
+	PreserveTitle       bool           // restore the old titles of migrated entities the new integration retitled
+	MigrateRelations    bool           // restore the old relations of migrated entities where they changed
+	MaxFailures         int            // abort once more requests than this failed, 0 means no limit
+	SummaryOnly         bool           // only print per-blueprint and overall results, not every batch
+	NoPromptBelow       int            // proceed without confirmation when fewer entities are affected, 0 always prompts
+	EmptySearchRetries  int            // re-search a blueprint that came back empty or 404 before accepting it
+	EmptySearchDelay    time.Duration  // wait before re-searching an empty blueprint
+	ManifestFile        string         // JSONL record of the patched entities, appended per blueprint
+	AllowUningested     bool           // migrate blueprints that have no entities on the new datasource yet
+	CountConcurrency    int            // blueprints searched at once while counting entities before the prompt
+	NoWarning           bool           // leave out the "cannot be undone" banner, the prompt still shows
+
+	MaxConsecutiveFailures int  // abort once this many batches failed in a row, 0 means no limit
+	JSONCompact            bool // write --output json on a single line instead of indented
+	VerifyIntegrity        bool // after patching, check the entities' data other than the datasource is unchanged
+	CheckOrphans           bool // report old entities with no counterpart on the new datasource before migrating
+	AllowOrphans           bool // migrate despite entities reported by CheckOrphans
+
+	ConfirmTimeout time.Duration // cancel when the confirmation wasn't typed in time, 0 waits forever
+	BatchDelay     time.Duration // pause between bulk patch batches of a blueprint, 0 doesn't pause
 }
 
 // MigrationStats holds migration statistics
 type MigrationStats struct {
-	TotalBlueprints   int
-	TotalEntities     int
-	TotalBatches      int
-	SuccessfulBatches int
-	FailedBatches     int
-	Errors            []string
+	TotalBlueprints   int      `json:"totalBlueprints"`
+	TotalEntities     int      `json:"totalEntities"`
+	TotalBatches      int      `json:"totalBatches"`
+	SuccessfulBatches int      `json:"successfulBatches"`
+	FailedBatches     int      `json:"failedBatches"`
+	Errors            []string `json:"errors,omitempty"`
+
+	// RetriedBlueprints lists blueprints that needed more than one attempt
+	RetriedBlueprints []string `json:"retriedBlueprints,omitempty"`
+
+	// LooseMatches lists, per blueprint, identifiers skipped in strict mode because
+	// their datasource only contains the old one
+	LooseMatches map[string][]string `json:"looseMatches,omitempty"`
+
+	// RestoredTitles counts the entities whose old title was restored by --preserve-title
+	RestoredTitles int `json:"restoredTitles,omitempty"`
+
+	// RestoredRelations counts the entities whose old relations were restored by --migrate-relations
+	RestoredRelations int `json:"restoredRelations,omitempty"`
+
+	// CircuitBreakerTripped is set when the migration was aborted by --max-failures or --max-consecutive-failures
+	CircuitBreakerTripped bool `json:"circuitBreakerTripped,omitempty"`
+
+	// DeadlineExceeded is set when the migration stopped at --deadline
+	DeadlineExceeded bool `json:"deadlineExceeded,omitempty"`
+
+	// PermissionDenied is set when Port refused a patch with a 403, the credentials are likely read-only
+	PermissionDenied bool `json:"permissionDenied,omitempty"`
+
+	// AbortReason says which circuit breaker, the deadline or a refused patch stopped the migration and why
+	AbortReason string `json:"abortReason,omitempty"`
+
+	// StaleBlueprints lists blueprints listed in the data-sources that no longer exist
+	StaleBlueprints []string `json:"staleBlueprints,omitempty"`
+
+	// UnverifiedEntities lists, per blueprint, patched identifiers that didn't show up on the new datasource
+	UnverifiedEntities map[string][]string `json:"unverifiedEntities,omitempty"`
+
+	// IntegrityMismatches lists, per blueprint, patched identifiers whose data other than the datasource changed
+	IntegrityMismatches map[string][]string `json:"integrityMismatches,omitempty"`
+
+	// PatchedEntities counts, per blueprint, the entities Port confirmed patching
+	PatchedEntities map[string]int `json:"patchedEntities,omitempty"`
+
+	// Failures lists the entities that failed to migrate, enough to re-run just them
+	Failures []FailedBatch `json:"failures,omitempty"`
+
+	// UningestedBlueprints lists blueprints with old entities but none on the new datasource yet
+	UningestedBlueprints []string `json:"uningestedBlueprints,omitempty"`
+
+	// AtRiskOrphans lists, per blueprint, old identifiers with no counterpart on the new datasource,
+	// found by --check-orphans. Migrating them would leave entities the new integration never updates
+	AtRiskOrphans map[string][]string `json:"atRiskOrphans,omitempty"`
+
+	// SourceDatasources counts, per blueprint, the matched entities by their actual old datasource,
+	// so a consolidation can confirm every old installation was covered
+	SourceDatasources map[string]map[string]int `json:"sourceDatasources,omitempty"`
+
+	// ErrorClasses counts the failed Port API requests by class: network, 5xx, 429, auth and 4xx-fatal
+	ErrorClasses map[string]int `json:"errorClasses,omitempty"`
+
+	// Skipped counts, per reason, the blueprints and entities left out of the migration's scope:
+	// resumeFrom, manifest, stale, uningested, strict and notOld
+	Skipped map[string]SkipCount `json:"skipped,omitempty"`
+}
+
+// SkipCount is how many blueprints and entities a filter left out of a migration
+type SkipCount struct {
+	Blueprints int `json:"blueprints"`
+	Entities   int `json:"entities"`
+}
+
+// DryRunSummary is the JSON representation of a dry run, for external approval workflows
+type DryRunSummary struct {
+	NewDatasource     string                    `json:"newDatasource"`
+	Blueprints        map[string]int            `json:"blueprints"` // blueprint -> entity count
+	TotalEntities     int                       `json:"totalEntities"`
+	StaleBlueprints   []string                  `json:"staleBlueprints,omitempty"`
+	Uningested        []string                  `json:"uningestedBlueprints,omitempty"`
+	SourceDatasources map[string]map[string]int `json:"sourceDatasources,omitempty"` // blueprint -> old datasource -> entity count
+	AtRiskOrphans     map[string][]string       `json:"atRiskOrphans,omitempty"`     // blueprint -> old identifiers missing on the new datasource
+
+	// Projection is each blueprint's counts on the old and new datasource now and after the migration,
+	// left out for blueprints whose new entities couldn't be counted
+	Projection map[string]DatasourceProjection `json:"projection,omitempty"`
+
+	Skipped map[string]SkipCount `json:"skipped,omitempty"` // reason -> blueprints and entities left out
+}
+
+// DatasourceProjection is a blueprint's entity counts on the old and the new datasource now,
+// and projected after the migration
+type DatasourceProjection struct {
+	OldNow   int `json:"oldNow"`
+	NewNow   int `json:"newNow"`
+	OldAfter int `json:"oldAfter"`
+	NewAfter int `json:"newAfter"`
+}
+
+// RollbackSummary is the JSON representation of a rollback dry run, the entities on the new
+// datasource that would be re-pointed to their old datasource
+type RollbackSummary struct {
+	Blueprints    map[string]int              `json:"blueprints"` // blueprint -> entity count
+	TotalEntities int                         `json:"totalEntities"`
+	Entities      map[string][]ManifestEntity `json:"entities"` // blueprint -> entities and the old datasource they'd get back
 }
 
 // DiffResult holds the comparison results
 type DiffResult struct {
-	SourceBlueprint string
-	TargetBlueprint string
-	Summary         DiffSummary
-	Changes         []EntityChange
+	SourceBlueprint   string
+	TargetBlueprint   string
+	Summary           DiffSummary
+	Changes           []EntityChange
+	NotFound          []string          // requested identifiers missing on both sides
+	LooseMatches      []string          // source identifiers dropped in strict mode, their datasource only contains the old one
+	NormalizedMatches []string          // "source → target" identifiers that only matched after normalization, e.g. --id-transform
+	Collisions        []string          // "a, b → key (side)" identifiers normalized to the same key, matched exactly instead
+	Insignificant     []string          // identifiers counted identical because they only differ outside the significant properties
+	OtherDatasources  map[string]string // not found identifier -> datasource it exists with, set by CompareEntity
 }
 
 // DiffSummary holds summary statistics
 type DiffSummary struct {
-	Identical   int
-	NotMigrated int
-	Changed     int
-	Orphaned    int
+	Identical   int `json:"identical"`
+	NotMigrated int `json:"notMigrated"`
+	Changed     int `json:"changed"`
+	Orphaned    int `json:"orphaned"`
+}
+
+// DatasourceDiagnosis holds the datasources actually present on a target blueprint
+type DatasourceDiagnosis struct {
+	Blueprint   string
+	Datasources map[string]int    // datasource -> entity count
+	Mismatched  map[string]string // not migrated identifier -> datasource it was found with
 }
 
 // EntityChange represents a single entity difference
 type EntityChange struct {
 	Identifier   string
+	Title        string // the old entity's title, the new one's for orphaned entities
 	Type         string // "identical", "changed", "notMigrated", "orphaned"
 	OldEntity    map[string]interface{}
 	NewEntity    map[string]interface{}
-	PropertyDiffs map[string]PropertyDiff
+	PropertyDiffs map[string]PropertyDiff // computed lazily, see diff.Service.PropertyDiffs
+	Kinds        []string                // kinds of difference of a changed entity: "property", "relation", "title", "meta"
+
+	// Compared entities, kept so property diffs can be computed on demand.
+	// Only Source is set for not migrated entities and only Target for orphaned ones.
+	Source *port.Entity `json:"-"`
+	Target *port.Entity `json:"-"`
 }
 
 // PropertyDiff represents a single property difference
@@ -50,3 +202,71 @@ type PropertyDiff struct {
 	NewValue interface{}
 }
 
+
+// PlanEntry represents a single reviewed datasource change
+type PlanEntry struct {
+	Blueprint     string
+	Identifier    string
+	OldDatasource string
+	NewDatasource string
+}
+
+// PlanSchemaVersion is the version of the JSON plan format, raised on any incompatible change
+const PlanSchemaVersion = 1
+
+// MigrationPlan is the JSON plan of the datasource changes a migrate or rollback dry run found,
+// for review and to execute exactly with --from-plan
+type MigrationPlan struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	Operation     string           `json:"operation"` // "migrate" or "rollback"
+	Transitions   []PlanTransition `json:"transitions"`
+}
+
+// PlanTransition is a set of a blueprint's entities moving from one datasource to another,
+// no identifiers stands for all of the blueprint's entities on fromDatasource
+type PlanTransition struct {
+	Blueprint      string   `json:"blueprint"`
+	Identifiers    []string `json:"identifiers"`
+	FromDatasource string   `json:"fromDatasource"`
+	ToDatasource   string   `json:"toDatasource"`
+}
+
+// FailedBatch is a set of a blueprint's entities that failed to migrate and why
+type FailedBatch struct {
+	Blueprint     string   `json:"blueprint"`
+	Identifiers   []string `json:"identifiers,omitempty"` // empty when the blueprint failed before its entities were known
+	NewDatasource string   `json:"newDatasource"`
+	Error         string   `json:"error"`
+}
+
+// ManifestEntry is a line of the migration manifest, the entities of a blueprint patched in one go
+type ManifestEntry struct {
+	Blueprint     string           `json:"blueprint"`
+	NewDatasource string           `json:"newDatasource"`
+	Entities      []ManifestEntity `json:"entities"`
+	Complete      bool             `json:"complete"` // false when the blueprint failed part way
+	CompletedAt   time.Time        `json:"completedAt"`
+}
+
+// ManifestEntity is a patched entity and the datasource it had before
+type ManifestEntity struct {
+	Identifier    string `json:"identifier"`
+	OldDatasource string `json:"oldDatasource"`
+	Checksum      string `json:"checksum,omitempty"` // hash of the title, properties and relations before the patch
+}
+
+// DiffOptions holds entity comparison options
+type DiffOptions struct {
+	IgnoreProperties     []string // glob patterns matched against flattened paths, e.g. "properties.sync_*"
+	SignificantProps     []string // when set, only differences in these flattened paths make an entity changed
+	NullEqualsMissing    bool     // treat null properties as equal to missing ones
+	Identifiers          []string // restrict the comparison to these entity identifiers
+	RelationsCompareMode string   // "normalized" (default), "exact", "ignore-order" or "keys-only"
+	ChangeKinds          []string // only these kinds of differences count: "property", "relation", "title", "meta"
+	IncludeMeta          bool     // compare createdAt, updatedAt, createdBy and updatedBy too
+	Strict               bool     // drop source entities whose datasource isn't exactly the old one
+	IgnoreCase           bool     // match source and target identifiers case-insensitively
+	IDTransforms         []string // built-in identifier transforms applied before matching, e.g. "slash-to-underscore"
+	JSONCompact          bool     // write --output json on a single line instead of indented
+	ShowTitles           bool     // list entities with their titles, not just their identifiers
+}
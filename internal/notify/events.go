@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/omby8888/port-github-migrator/internal/redact"
+)
+
+// Event types emitted by migrate and get-diff
+const (
+	EventMigrationStarted  = "migration.started"
+	EventBlueprintMigrated = "migration.blueprintDone"
+	EventMigrationFinished = "migration.finished"
+	EventBlueprintCompared = "diff.blueprintCompared"
+)
+
+// Event is a structured record of a migration or diff step, for audit and observability systems
+type Event struct {
+	Type      string                 `json:"type"`
+	Time      time.Time              `json:"time"`
+	Blueprint string                 `json:"blueprint,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// NewEvent returns an event of the given type stamped with the current time
+func NewEvent(eventType, blueprint string, data map[string]interface{}) Event {
+	return Event{Type: eventType, Time: time.Now().UTC(), Blueprint: blueprint, Data: data}
+}
+
+// EventSink receives the events, implementations are safe for concurrent use
+type EventSink interface {
+	Emit(event Event) error
+}
+
+// Emit sends the event to the sink, doing nothing when there is no sink
+func Emit(sink EventSink, event Event) error {
+	if sink == nil {
+		return nil
+	}
+	return sink.Emit(event)
+}
+
+// FileSink appends each event as a JSON line to a local audit log
+type FileSink struct {
+	mu      sync.Mutex
+	f       *os.File
+	secrets []string
+}
+
+// NewFileSink opens the audit log for appending, creating it if needed. The secrets are masked
+// wherever they show up in an event.
+func NewFileSink(path string, secrets ...string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileSink{f: f, secrets: secrets}, nil
+}
+
+func (s *FileSink) Emit(event Event) error {
+	line := marshalRedacted(event, s.secrets)
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the audit log
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
+
+// HTTPSink posts each event as JSON to a URL
+type HTTPSink struct {
+	url     string
+	client  *http.Client
+	secrets []string
+}
+
+// NewHTTPSink returns a sink posting to url, masking the secrets wherever they show up in an event
+func NewHTTPSink(url string, secrets ...string) *HTTPSink {
+	return &HTTPSink{url: url, client: &http.Client{Timeout: 10 * time.Second}, secrets: secrets}
+}
+
+func (s *HTTPSink) Emit(event Event) error {
+	bodyBytes := marshalRedacted(event, s.secrets)
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("event request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("event sink returned %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// marshalRedacted encodes the event as JSON with the secrets masked
+func marshalRedacted(event Event, secrets []string) []byte {
+	encoded, _ := json.Marshal(event)
+	return []byte(redact.Text(string(encoded), secrets...))
+}
+
+// MultiSink sends each event to all of its sinks, returning the first error
+type MultiSink []EventSink
+
+func (m MultiSink) Emit(event Event) error {
+	var first error
+	for _, sink := range m {
+		if err := sink.Emit(event); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+const secret = "client-secret-value"
+
+// recordBodies returns a server recording the bodies posted to it
+func recordBodies(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies
+}
+
+func TestFileSinkMasksSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Emit(NewEvent(EventMigrationFinished, "", map[string]interface{}{"error": "auth failed for " + secret})); err != nil {
+		t.Fatalf("Emit() failed: %v", err)
+	}
+	sink.Close()
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(written), secret) {
+		t.Errorf("audit log contains the secret: %s", written)
+	}
+}
+
+func TestHTTPSinkMasksSecrets(t *testing.T) {
+	srv, bodies := recordBodies(t)
+
+	sink := NewHTTPSink(srv.URL, secret)
+	if err := sink.Emit(NewEvent(EventMigrationFinished, "", map[string]interface{}{"error": "Bearer " + secret})); err != nil {
+		t.Fatalf("Emit() failed: %v", err)
+	}
+
+	if len(*bodies) != 1 {
+		t.Fatalf("got %d requests, want 1", len(*bodies))
+	}
+	if strings.Contains((*bodies)[0], secret) {
+		t.Errorf("event contains the secret: %s", (*bodies)[0])
+	}
+}
+
+func TestSendWebhookMasksSecrets(t *testing.T) {
+	srv, bodies := recordBodies(t)
+
+	stats := &models.MigrationStats{Errors: []string{"Failed to migrate blueprint repo: auth failed for " + secret}}
+	if err := SendWebhook(srv.URL, stats, errors.New("request to https://x/"+secret+" failed"), secret); err != nil {
+		t.Fatalf("SendWebhook() failed: %v", err)
+	}
+
+	if len(*bodies) != 1 {
+		t.Fatalf("got %d requests, want 1", len(*bodies))
+	}
+	if strings.Contains((*bodies)[0], secret) {
+		t.Errorf("webhook payload contains the secret: %s", (*bodies)[0])
+	}
+	if !strings.Contains((*bodies)[0], `"status":"failure"`) {
+		t.Errorf("webhook payload lost its status: %s", (*bodies)[0])
+	}
+}
+
+func TestSendWebhookStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		stats        *models.MigrationStats
+		migrationErr error
+		want         string
+	}{
+		{"success", &models.MigrationStats{SuccessfulBatches: 1}, nil, "success"},
+		{"failed blueprint", &models.MigrationStats{FailedBatches: 1}, nil, "failure"},
+		{"error", nil, errors.New("failed to get blueprints"), "failure"},
+		{"deadline", &models.MigrationStats{DeadlineExceeded: true}, errors.New("stopped at the --deadline"), "timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, bodies := recordBodies(t)
+			if err := SendWebhook(srv.URL, tt.stats, tt.migrationErr); err != nil {
+				t.Fatalf("SendWebhook() failed: %v", err)
+			}
+			if want := `"status":"` + tt.want + `"`; !strings.Contains((*bodies)[0], want) {
+				t.Errorf("payload %s doesn't contain %s", (*bodies)[0], want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/redact"
+)
+
+// WebhookPayload is the JSON body posted to the notification webhook
+type WebhookPayload struct {
+	Status string                 `json:"status"` // "success", "failure" or "timeout"
+	Error  string                 `json:"error,omitempty"`
+	Stats  *models.MigrationStats `json:"stats,omitempty"`
+}
+
+// SendWebhook posts the migration outcome to a webhook URL, masking the secrets in the payload
+func SendWebhook(url string, stats *models.MigrationStats, migrationErr error, secrets ...string) error {
+	payload := WebhookPayload{
+		Status: "success",
+		Stats:  stats,
+	}
+	if migrationErr != nil {
+		payload.Status = "failure"
+		payload.Error = migrationErr.Error()
+	} else if stats != nil && stats.FailedBatches > 0 {
+		payload.Status = "failure"
+	}
+	if stats != nil && stats.DeadlineExceeded {
+		payload.Status = "timeout"
+	}
+
+	bodyBytes, _ := json.Marshal(payload)
+	bodyBytes = []byte(redact.Text(string(bodyBytes), secrets...))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package port
+
+import (
+	"net/http"
+)
+
+// Classes of failed requests, to tell retryable trouble from errors that need fixing
+const (
+	ErrorClassNetwork     = "network"   // transport error, no response
+	ErrorClassServer      = "5xx"       // Port or a proxy failed, retryable
+	ErrorClassRateLimited = "429"       // rate limited, lower --max-rps or the concurrency
+	ErrorClassAuth        = "auth"      // 401 or 403, fix the credentials
+	ErrorClassClient      = "4xx-fatal" // any other 4xx, retrying won't help
+)
+
+// errorClasses lists the error classes in the order they are reported
+var errorClasses = [...]string{ErrorClassNetwork, ErrorClassServer, ErrorClassRateLimited, ErrorClassAuth, ErrorClassClient}
+
+// ClassifyResponse returns the error class of a request's outcome, err being the transport error,
+// or "" when the request succeeded
+func ClassifyResponse(resp *http.Response, err error) string {
+	switch {
+	case err != nil || resp == nil:
+		return ErrorClassNetwork
+	case resp.StatusCode >= 500:
+		return ErrorClassServer
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return ErrorClassAuth
+	case resp.StatusCode >= 400:
+		return ErrorClassClient
+	}
+	return ""
+}
+
+// recordErrorClass counts a failed request by its class
+func (c *Client) recordErrorClass(class string) {
+	for i, known := range errorClasses {
+		if known == class {
+			c.errorCounts[i].Add(1)
+			return
+		}
+	}
+}
+
+// ErrorClasses returns how many requests failed per error class, leaving out the classes that
+// didn't occur. 404s are included although some, such as a since deleted blueprint, are expected.
+func (c *Client) ErrorClasses() map[string]int {
+	counts := make(map[string]int)
+	for i, class := range errorClasses {
+		if n := c.errorCounts[i].Load(); n > 0 {
+			counts[class] = int(n)
+		}
+	}
+	return counts
+}
+
+// ErrorClassNames returns the error classes in the order they are reported
+func ErrorClassNames() []string {
+	return append([]string(nil), errorClasses[:]...)
+}
@@ -2,22 +2,79 @@ package port
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // Client handles all Port API interactions
 type Client struct {
-	baseURL        string
-	clientID       string
-	clientSecret   string
-	httpClient     *http.Client
-	token          string
-	tokenExpires   time.Time
+	baseURL      string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+	tokenMu      sync.Mutex
+	token        string
+	tokenExpires time.Time
+	limiter      *rate.Limiter
+
+	maxPatchBodySize int
+	searchProgress   SearchProgressFunc
+	headers          http.Header
+	userAgent        string
+	log              io.Writer // verbose diagnostics, nil discards them
+	ctx              context.Context
+
+	// apiVersion prefixes every path and searchPath follows it for entity searches, see paths.go
+	apiVersion string
+	searchPath string
+
+	// newDatasourceKind and newDatasourceSuffix make up the new installation's datasource,
+	// <kind>/<version>/<new-installation-id>/<suffix>
+	newDatasourceKind   string
+	newDatasourceSuffix string
+
+	// failedRequests counts requests that failed from a transport error, a 429 or a 5xx
+	failedRequests atomic.Int64
+
+	// errorCounts counts failed requests per class, in the order of errorClasses
+	errorCounts [len(errorClasses)]atomic.Int64
+
+	// aggregateUnsupported is set once the aggregate endpoint answers 501 or 405, counts then use search
+	aggregateUnsupported atomic.Bool
+}
+
+// Default parts of the GitHub Ocean datasource, port-ocean/github-ocean/<version>/<new-installation-id>/exporter
+const (
+	DefaultNewDatasourceKind   = "port-ocean/github-ocean"
+	DefaultNewDatasourceSuffix = "exporter"
+)
+
+// DefaultMaxPatchBodySize is the default bulk patch body size above which batches are split
+const DefaultMaxPatchBodySize = 1 << 20
+
+// UserAgentProduct is the product of the default User-Agent, port-github-migrator/<version>
+const UserAgentProduct = "port-github-migrator"
+
+// Option configures optional client behavior
+type Option func(*Client)
+
+// WithMaxRPS caps the rate of outgoing requests, 0 means unlimited
+func WithMaxRPS(rps float64) Option {
+	return func(c *Client) {
+		if rps > 0 {
+			c.limiter = rate.NewLimiter(rate.Limit(rps), 1)
+		}
+	}
 }
 
 // AuthResponse represents the response from auth endpoint
@@ -55,6 +112,9 @@ type SearchResponse struct {
 }
 
 // Entity represents a Port entity
+//
+// Datasource holds the entity's $datasource meta-property, the value the
+// search queries filter on. It is empty when Port doesn't return it.
 type Entity struct {
 	Identifier string                 `json:"identifier"`
 	Title      string                 `json:"title,omitempty"`
@@ -65,6 +125,7 @@ type Entity struct {
 	UpdatedBy  string                 `json:"updatedBy,omitempty"`
 	Properties map[string]interface{} `json:"properties,omitempty"`
 	Relations  interface{}            `json:"relations,omitempty"`
+	Datasource string                 `json:"$datasource,omitempty"`
 }
 
 // BulkPatchRequest represents a bulk patch request
@@ -73,18 +134,186 @@ type BulkPatchRequest struct {
 	Datasource          string   `json:"datasource"`
 }
 
+// BulkPatchResponse represents the optional per-entity details of a bulk patch response
+type BulkPatchResponse struct {
+	Entities []struct {
+		Identifier string `json:"identifier"`
+	} `json:"entities"`
+	Errors []struct {
+		Identifier string `json:"identifier"`
+		Message    string `json:"message"`
+	} `json:"errors"`
+}
+
+// BulkPatchResult holds the per-entity outcome of a bulk patch
+type BulkPatchResult struct {
+	Confirmed []string          // identifiers the patch succeeded for
+	Failed    map[string]string // identifier -> error message
+	Requests  int               // number of requests sent, more than 1 when the batch was split
+}
+
+// merge adds the outcome of another part of a split batch, which may be nil when its request failed
+func (r *BulkPatchResult) merge(other *BulkPatchResult) *BulkPatchResult {
+	if other == nil {
+		return r
+	}
+	r.Confirmed = append(r.Confirmed, other.Confirmed...)
+	for id, message := range other.Failed {
+		r.Failed[id] = message
+	}
+	r.Requests += other.Requests
+	return r
+}
+
+// WithHeaders adds custom headers to every request, e.g. for a gateway in front of the Port API.
+// The Authorization header is always the client's own and can't be overridden.
+func WithHeaders(headers http.Header) Option {
+	return func(c *Client) {
+		c.headers = headers
+	}
+}
+
+// WithUserAgent sets the User-Agent of every request, so Port's logs tell the tool's traffic apart.
+// A User-Agent passed with WithHeaders takes precedence.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithContext bounds every request and rate limiter wait by ctx, e.g. a deadline on the whole run.
+// Requests in flight when ctx ends are cancelled.
+func WithContext(ctx context.Context) Option {
+	return func(c *Client) {
+		if ctx != nil {
+			c.ctx = ctx
+		}
+	}
+}
+
+// WithLog writes verbose client diagnostics, such as collapsed duplicate search results, to w
+func WithLog(w io.Writer) Option {
+	return func(c *Client) {
+		c.log = w
+	}
+}
+
+// SearchProgressFunc is called after each search page with the entities fetched and pages read so far.
+// Concurrent searches call it from their own goroutines.
+type SearchProgressFunc func(blueprintID string, fetched, pages int)
+
+// WithSearchProgress reports entity search progress after every page, nil disables it
+func WithSearchProgress(fn SearchProgressFunc) Option {
+	return func(c *Client) {
+		c.searchProgress = fn
+	}
+}
+
+// WithMaxPatchBodySize overrides the bulk patch body size in bytes above which batches are split, 0 disables splitting
+func WithMaxPatchBodySize(size int) Option {
+	return func(c *Client) {
+		c.maxPatchBodySize = size
+	}
+}
+
+// WithNewDatasource overrides the kind and suffix of the new datasource for Ocean integrations
+// that don't use the standard ones, an empty value keeps the default
+func WithNewDatasource(kind, suffix string) Option {
+	return func(c *Client) {
+		if kind != "" {
+			c.newDatasourceKind = kind
+		}
+		if suffix != "" {
+			c.newDatasourceSuffix = suffix
+		}
+	}
+}
+
 // NewClient creates a new Port API client
-func NewClient(baseURL, clientID, clientSecret string) *Client {
-	return &Client{
+func NewClient(baseURL, clientID, clientSecret string, opts ...Option) *Client {
+	c := &Client{
 		baseURL:      strings.TrimSuffix(baseURL, "/"),
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		ctx:          context.Background(),
+
+		maxPatchBodySize:    DefaultMaxPatchBodySize,
+		apiVersion:          DefaultAPIVersion,
+		searchPath:          DefaultSearchPath,
+		newDatasourceKind:   DefaultNewDatasourceKind,
+		newDatasourceSuffix: DefaultNewDatasourceSuffix,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// do sends a request, counting it toward FailedRequests and ErrorClasses when it fails
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.send(req)
+	c.recordOutcome(resp, err)
+	return resp, err
+}
+
+// send sends a request with the custom headers, waiting for the rate limiter first when one is configured
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(c.ctx); err != nil {
+			// The limiter refuses upfront a wait past the deadline, no later request would make it either
+			if _, ok := c.ctx.Deadline(); ok {
+				<-c.ctx.Done()
+				return nil, c.ctx.Err()
+			}
+			return nil, err
+		}
+	}
+
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	for key, values := range c.headers {
+		if http.CanonicalHeaderKey(key) == "Authorization" {
+			continue
+		}
+		req.Header.Del(key)
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	return c.httpClient.Do(req.WithContext(c.ctx))
+}
+
+// recordOutcome counts a failed request toward FailedRequests and ErrorClasses,
+// a request cancelled by the client's context didn't fail on Port's side and isn't counted
+func (c *Client) recordOutcome(resp *http.Response, err error) {
+	if err != nil && c.ctx.Err() != nil {
+		return
+	}
+	if err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		c.failedRequests.Add(1)
 	}
+	if class := ClassifyResponse(resp, err); class != "" {
+		c.recordErrorClass(class)
+	}
+}
+
+// FailedRequests returns how many requests failed from a transport error, a 429 or a 5xx,
+// the signs of a degraded API as opposed to rejected input
+func (c *Client) FailedRequests() int {
+	return int(c.failedRequests.Load())
 }
 
-// getToken returns a valid access token, refreshing if necessary
+// getToken returns a valid access token, refreshing if necessary.
+// It is safe to call from multiple goroutines.
 func (c *Client) getToken() (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
 	now := time.Now()
 	threeMinutes := 3 * time.Minute
 
@@ -100,19 +329,21 @@ func (c *Client) getToken() (string, error) {
 	}
 	bodyBytes, _ := json.Marshal(body)
 
-	resp, err := c.httpClient.Post(
-		fmt.Sprintf("%s/v1/auth/access_token", c.baseURL),
-		"application/json",
+	req, _ := http.NewRequest(
+		"POST",
+		c.endpoint(accessTokenPath),
 		bytes.NewReader(bodyBytes),
 	)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("authentication request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("authentication failed: %s", string(body))
+		return "", newAPIError("authentication", resp)
 	}
 
 	var authResp AuthResponse
@@ -126,6 +357,12 @@ func (c *Client) getToken() (string, error) {
 	return c.token, nil
 }
 
+// Authenticate checks the credentials by fetching an access token
+func (c *Client) Authenticate() error {
+	_, err := c.getToken()
+	return err
+}
+
 // GetIntegrationVersion fetches the version of an integration
 func (c *Client) GetIntegrationVersion(installationID string) (string, error) {
 	token, err := c.getToken()
@@ -135,12 +372,12 @@ func (c *Client) GetIntegrationVersion(installationID string) (string, error) {
 
 	req, _ := http.NewRequest(
 		"GET",
-		fmt.Sprintf("%s/v1/integration/%s", c.baseURL, installationID),
+		c.endpoint(integrationPath, "installationId", installationID),
 		nil,
 	)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("request failed: %w", err)
 	}
@@ -151,8 +388,7 @@ func (c *Client) GetIntegrationVersion(installationID string) (string, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("request failed: %s", string(body))
+		return "", newAPIError("request", resp)
 	}
 
 	var intResp IntegrationResponse
@@ -167,8 +403,42 @@ func (c *Client) GetIntegrationVersion(installationID string) (string, error) {
 	return intResp.Integration.Version, nil
 }
 
-// GetBlueprintsByDataSource fetches all blueprints for an installation
+// GetBlueprintsByDataSource fetches all blueprints for an installation, the old or the new one,
+// failing when it has none
 func (c *Client) GetBlueprintsByDataSource(installationID string) ([]string, error) {
+	dsResp, err := c.GetDataSources()
+	if err != nil {
+		return nil, err
+	}
+
+	result := dsResp.BlueprintsOf(installationID)
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no blueprints found for installation: %s", installationID)
+	}
+
+	return result, nil
+}
+
+// GetBlueprintsByInstallations fetches the blueprints of each of the installations from a single
+// datasources response, e.g. the old and the new one side by side. An installation without any
+// datasource gets no blueprints rather than an error.
+func (c *Client) GetBlueprintsByInstallations(installationIDs ...string) (map[string][]string, error) {
+	dsResp, err := c.GetDataSources()
+	if err != nil {
+		return nil, err
+	}
+
+	blueprints := make(map[string][]string, len(installationIDs))
+	for _, id := range installationIDs {
+		result := dsResp.BlueprintsOf(id)
+		sort.Strings(result)
+		blueprints[id] = result
+	}
+	return blueprints, nil
+}
+
+// GetDataSources fetches the datasources of every installation with the blueprints they ingest into
+func (c *Client) GetDataSources() (*DataSourceResponse, error) {
 	token, err := c.getToken()
 	if err != nil {
 		return nil, err
@@ -176,20 +446,19 @@ func (c *Client) GetBlueprintsByDataSource(installationID string) ([]string, err
 
 	req, _ := http.NewRequest(
 		"GET",
-		fmt.Sprintf("%s/v1/data-sources", c.baseURL),
+		c.endpoint(dataSourcesPath),
 		nil,
 	)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("request failed: %s", string(body))
+		return nil, newAPIError("request", resp)
 	}
 
 	var dsResp DataSourceResponse
@@ -197,9 +466,14 @@ func (c *Client) GetBlueprintsByDataSource(installationID string) ([]string, err
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	return &dsResp, nil
+}
+
+// BlueprintsOf returns the blueprints the installation's datasources ingest into, empty when it has none
+func (r *DataSourceResponse) BlueprintsOf(installationID string) []string {
 	// Filter datasources by installation ID
 	blueprints := make(map[string]bool)
-	for _, ds := range dsResp.DataSources {
+	for _, ds := range r.DataSources {
 		if ds.Context.InstallationID == installationID {
 			for _, bp := range ds.Blueprints {
 				blueprints[bp.Identifier] = true
@@ -207,29 +481,45 @@ func (c *Client) GetBlueprintsByDataSource(installationID string) ([]string, err
 		}
 	}
 
-	if len(blueprints) == 0 {
-		return nil, fmt.Errorf("no blueprints found for installation: %s", installationID)
-	}
-
 	// Convert map to slice
 	result := make([]string, 0, len(blueprints))
 	for bp := range blueprints {
 		result = append(result, bp)
 	}
 
-	return result, nil
+	return result
 }
 
+// SearchPageSize is the number of entities fetched per search request
+const SearchPageSize = 200
+
 // searchEntitiesByBlueprint searches for entities with optional query
 func (c *Client) searchEntitiesByBlueprint(blueprintID string, query map[string]interface{}) ([]Entity, error) {
+	return c.searchEntities(blueprintID, query, 0, nil)
+}
+
+// identifierFields are the only fields returned by the identifier searches
+var identifierFields = []string{"$identifier", "$datasource"}
+
+// searchEntities pages through the search results, stopping once it has maxEntities, 0 reads every page.
+// When include is set only those fields of the entities are returned.
+func (c *Client) searchEntities(blueprintID string, query map[string]interface{}, maxEntities int, include []string) ([]Entity, error) {
 	token, err := c.getToken()
 	if err != nil {
 		return nil, err
 	}
 
 	allEntities := []Entity{}
-	limit := 200
+	limit := SearchPageSize
+	if maxEntities > 0 && maxEntities < limit {
+		limit = maxEntities
+	}
 	var next string
+	pages := 0
+
+	// Overlapping pagination cursors could return an entity twice, inflating counts and patches
+	seen := make(map[string]bool)
+	duplicates := 0
 
 	for {
 		reqBody := map[string]interface{}{
@@ -240,6 +530,10 @@ func (c *Client) searchEntitiesByBlueprint(blueprintID string, query map[string]
 			reqBody["query"] = query
 		}
 
+		if include != nil {
+			reqBody["include"] = include
+		}
+
 		if next != "" {
 			reqBody["from"] = next
 		}
@@ -248,21 +542,20 @@ func (c *Client) searchEntitiesByBlueprint(blueprintID string, query map[string]
 
 		req, _ := http.NewRequest(
 			"POST",
-			fmt.Sprintf("%s/v1/blueprints/%s/entities/search", c.baseURL, blueprintID),
+			c.endpoint(c.searchPath, "blueprint", blueprintID),
 			bytes.NewReader(bodyBytes),
 		)
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 		req.Header.Set("Content-Type", "application/json")
 
-		resp, err := c.httpClient.Do(req)
+		resp, err := c.do(req)
 		if err != nil {
 			return nil, fmt.Errorf("request failed: %w", err)
 		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("search failed: %s", string(body))
+			return nil, newAPIError("search", resp)
 		}
 
 		var searchResp SearchResponse
@@ -270,8 +563,23 @@ func (c *Client) searchEntitiesByBlueprint(blueprintID string, query map[string]
 			return nil, fmt.Errorf("failed to decode response: %w", err)
 		}
 
-		allEntities = append(allEntities, searchResp.Entities...)
+		for _, e := range searchResp.Entities {
+			if seen[e.Identifier] {
+				duplicates++
+				continue
+			}
+			seen[e.Identifier] = true
+			allEntities = append(allEntities, e)
+		}
+		pages++
+		if c.searchProgress != nil {
+			c.searchProgress(blueprintID, len(allEntities), pages)
+		}
 
+		if maxEntities > 0 && len(allEntities) >= maxEntities {
+			allEntities = allEntities[:maxEntities]
+			break
+		}
 		if searchResp.Next == "" {
 			break
 		}
@@ -279,60 +587,213 @@ func (c *Client) searchEntitiesByBlueprint(blueprintID string, query map[string]
 		next = searchResp.Next
 	}
 
+	if duplicates > 0 && c.log != nil {
+		fmt.Fprintf(c.log, "⚠️  %s: collapsed %d duplicate entities returned across search pages\n", blueprintID, duplicates)
+	}
+
 	return allEntities, nil
 }
 
+// OldDatasourceKind is the datasource prefix used by the legacy GitHub App integration
+const OldDatasourceKind = "port/github/v1.0.0"
+
+// OldDatasource returns the datasource string of the legacy GitHub App installation
+func OldDatasource(oldInstallationID string) string {
+	return fmt.Sprintf("%s/%s", OldDatasourceKind, oldInstallationID)
+}
+
+// SplitByDatasource separates entities whose $datasource is exactly datasource from the rest.
+// Entities without a $datasource can't be checked and count as exact.
+func SplitByDatasource(entities []Entity, datasource string) (exact, other []Entity) {
+	for _, e := range entities {
+		if e.Datasource == "" || e.Datasource == datasource {
+			exact = append(exact, e)
+		} else {
+			other = append(other, e)
+		}
+	}
+	return exact, other
+}
+
 // SearchOldEntitiesByBlueprint searches for old GitHub App entities
 func (c *Client) SearchOldEntitiesByBlueprint(blueprintID, oldInstallationID string) ([]Entity, error) {
 	query := map[string]interface{}{
 		"combinator": "and",
-		"rules": []map[string]interface{}{
-			{
-				"property": "$datasource",
-				"operator": "contains",
-				"value":    "port/github/v1.0.0",
-			},
-			{
-				"property": "$datasource",
-				"operator": "contains",
-				"value":    oldInstallationID,
-			},
-		},
+		"rules":      oldDatasourceRules(oldInstallationID),
 	}
 
 	return c.searchEntitiesByBlueprint(blueprintID, query)
 }
 
+// SampleOldEntities returns up to n old GitHub App entities of a blueprint, usually from the first search page
+func (c *Client) SampleOldEntities(blueprintID, oldInstallationID string, n int) ([]Entity, error) {
+	query := map[string]interface{}{
+		"combinator": "and",
+		"rules":      oldDatasourceRules(oldInstallationID),
+	}
+
+	return c.searchEntities(blueprintID, query, n, nil)
+}
+
+// SearchOldIdentifiers searches for old GitHub App entities, returning just their identifier and datasource
+func (c *Client) SearchOldIdentifiers(blueprintID, oldInstallationID string) ([]Entity, error) {
+	query := map[string]interface{}{
+		"combinator": "and",
+		"rules":      oldDatasourceRules(oldInstallationID),
+	}
+
+	return c.searchEntities(blueprintID, query, 0, identifierFields)
+}
+
+// oldDatasourceRules matches the datasource of the legacy GitHub App installation
+func oldDatasourceRules(oldInstallationID string) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"property": "$datasource",
+			"operator": "contains",
+			"value":    OldDatasourceKind,
+		},
+		{
+			"property": "$datasource",
+			"operator": "contains",
+			"value":    oldInstallationID,
+		},
+	}
+}
+
 // SearchNewEntitiesByBlueprint searches for new GitHub Ocean entities
 func (c *Client) SearchNewEntitiesByBlueprint(blueprintID, newInstallationID string) ([]Entity, error) {
 	query := map[string]interface{}{
 		"combinator": "and",
-		"rules": []map[string]interface{}{
-			{
-				"property": "$datasource",
-				"operator": "contains",
-				"value":    "port-ocean/github-ocean",
-			},
-			{
-				"property": "$datasource",
-				"operator": "contains",
-				"value":    fmt.Sprintf("%s/exporter", newInstallationID),
-			},
+		"rules":      c.newDatasourceRules(newInstallationID),
+	}
+
+	return c.searchEntitiesByBlueprint(blueprintID, query)
+}
+
+// SearchNewIdentifiers searches for new GitHub Ocean entities, returning just their identifier and datasource
+func (c *Client) SearchNewIdentifiers(blueprintID, newInstallationID string) ([]Entity, error) {
+	query := map[string]interface{}{
+		"combinator": "and",
+		"rules":      c.newDatasourceRules(newInstallationID),
+	}
+
+	return c.searchEntities(blueprintID, query, 0, identifierFields)
+}
+
+// NewDatasource returns the datasource of the new GitHub Ocean installation at the integration version
+func (c *Client) NewDatasource(version, newInstallationID string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", c.newDatasourceKind, version, newInstallationID, c.newDatasourceSuffix)
+}
+
+// newDatasourceRules matches the datasource of the new GitHub Ocean installation
+func (c *Client) newDatasourceRules(newInstallationID string) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"property": "$datasource",
+			"operator": "contains",
+			"value":    c.newDatasourceKind,
 		},
+		{
+			"property": "$datasource",
+			"operator": "contains",
+			"value":    fmt.Sprintf("%s/%s", newInstallationID, c.newDatasourceSuffix),
+		},
+	}
+}
+
+// SearchOldEntity searches for a single old GitHub App entity, returning nil when it doesn't exist
+func (c *Client) SearchOldEntity(blueprintID, oldInstallationID, identifier string) (*Entity, error) {
+	return c.searchEntity(blueprintID, identifier, oldDatasourceRules(oldInstallationID))
+}
+
+// SearchNewEntity searches for a single new GitHub Ocean entity, returning nil when it doesn't exist
+func (c *Client) SearchNewEntity(blueprintID, newInstallationID, identifier string) (*Entity, error) {
+	return c.searchEntity(blueprintID, identifier, c.newDatasourceRules(newInstallationID))
+}
+
+// searchEntity searches for one entity by identifier among the entities matching the datasource rules
+func (c *Client) searchEntity(blueprintID, identifier string, datasourceRules []map[string]interface{}) (*Entity, error) {
+	rules := append(datasourceRules, map[string]interface{}{
+		"property": "$identifier",
+		"operator": "=",
+		"value":    identifier,
+	})
+	query := map[string]interface{}{
+		"combinator": "and",
+		"rules":      rules,
+	}
+
+	entities, err := c.searchEntitiesByBlueprint(blueprintID, query)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entities {
+		if entities[i].Identifier == identifier {
+			return &entities[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// SearchAllEntitiesByBlueprint searches for all entities of a blueprint regardless of datasource
+func (c *Client) SearchAllEntitiesByBlueprint(blueprintID string) ([]Entity, error) {
+	query := map[string]interface{}{
+		"combinator": "and",
+		"rules":      []map[string]interface{}{},
 	}
 
 	return c.searchEntitiesByBlueprint(blueprintID, query)
 }
 
+// CheckWriteAccess checks the credentials may patch the blueprint's entities by sending a bulk datasource
+// patch of no entities, which changes nothing. A 2xx, or Port rejecting the empty payload with a JSON 400
+// or 422 after the permission check, means write access and a 401 or 403 means none. Any other response
+// proves neither and returns an error wrapping ErrInconclusive.
+func (c *Client) CheckWriteAccess(blueprintID, datasource string) error {
+	token, err := c.getToken()
+	if err != nil {
+		return err
+	}
+
+	bodyBytes, _ := json.Marshal(BulkPatchRequest{EntitiesIdentifiers: []string{}, Datasource: datasource})
+	req, _ := http.NewRequest(
+		"PATCH",
+		c.endpoint(datasourceBulkPath, "blueprint", blueprintID),
+		bytes.NewReader(bodyBytes),
+	)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return newAPIError("patch", resp)
+	case (resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity) && isJSONResponse(resp):
+		return nil
+	}
+	apiErr := newAPIError("patch", resp)
+	return fmt.Errorf("%w, the write probe got status %d: %s", ErrInconclusive, apiErr.StatusCode, apiErr.Body)
+}
+
 // PatchEntitiesDatasourceBulk updates entities' datasource in bulk
-func (c *Client) PatchEntitiesDatasourceBulk(blueprintID string, entitiesIdentifiers []string, newDatasource string) error {
+func (c *Client) PatchEntitiesDatasourceBulk(blueprintID string, entitiesIdentifiers []string, newDatasource string) (*BulkPatchResult, error) {
+	result := &BulkPatchResult{Failed: make(map[string]string), Requests: 1}
 	if len(entitiesIdentifiers) == 0 {
-		return nil
+		result.Requests = 0
+		return result, nil
 	}
 
 	token, err := c.getToken()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	payload := BulkPatchRequest{
@@ -342,25 +803,137 @@ func (c *Client) PatchEntitiesDatasourceBulk(blueprintID string, entitiesIdentif
 
 	bodyBytes, _ := json.Marshal(payload)
 
+	// Split batches whose body would exceed the server limit instead of failing opaquely
+	if c.maxPatchBodySize > 0 && len(bodyBytes) > c.maxPatchBodySize && len(entitiesIdentifiers) > 1 {
+		half := len(entitiesIdentifiers) / 2
+		first, err := c.PatchEntitiesDatasourceBulk(blueprintID, entitiesIdentifiers[:half], newDatasource)
+		if err != nil {
+			// A nested split may have patched part of the first half, report it alongside the error
+			return first, err
+		}
+		second, err := c.PatchEntitiesDatasourceBulk(blueprintID, entitiesIdentifiers[half:], newDatasource)
+		return first.merge(second), err
+	}
+
+	req, _ := http.NewRequest(
+		"PATCH",
+		c.endpoint(datasourceBulkPath, "blueprint", blueprintID),
+		bytes.NewReader(bodyBytes),
+	)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("patch", resp)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	// The response may report per-entity outcomes, without them the whole batch is confirmed
+	var patchResp BulkPatchResponse
+	if err := json.Unmarshal(body, &patchResp); err != nil || (len(patchResp.Entities) == 0 && len(patchResp.Errors) == 0) {
+		result.Confirmed = entitiesIdentifiers
+		return result, nil
+	}
+
+	for _, e := range patchResp.Errors {
+		result.Failed[e.Identifier] = e.Message
+	}
+
+	if len(patchResp.Entities) > 0 {
+		reported := make(map[string]bool, len(patchResp.Entities))
+		for _, e := range patchResp.Entities {
+			reported[e.Identifier] = true
+			if _, failed := result.Failed[e.Identifier]; !failed {
+				result.Confirmed = append(result.Confirmed, e.Identifier)
+			}
+		}
+		// An identifier in neither list may or may not have been patched, don't count it as migrated
+		for _, id := range entitiesIdentifiers {
+			if _, failed := result.Failed[id]; !failed && !reported[id] {
+				result.Failed[id] = "not confirmed by Port"
+			}
+		}
+	} else {
+		for _, id := range entitiesIdentifiers {
+			if _, failed := result.Failed[id]; !failed {
+				result.Confirmed = append(result.Confirmed, id)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// EntityResponse represents a single entity fetched by identifier
+type EntityResponse struct {
+	Entity Entity `json:"entity"`
+}
+
+// GetEntity fetches a single entity by identifier, regardless of its datasource.
+// A missing entity returns an error for which IsNotFound reports true.
+func (c *Client) GetEntity(blueprintID, identifier string) (*Entity, error) {
+	token, err := c.getToken()
+	if err != nil {
+		return nil, err
+	}
+
+	req, _ := http.NewRequest(
+		"GET",
+		c.endpoint(entityPath, "blueprint", blueprintID, "identifier", identifier),
+		nil,
+	)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("get entity", resp)
+	}
+
+	var entityResp EntityResponse
+	if err := json.NewDecoder(resp.Body).Decode(&entityResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &entityResp.Entity, nil
+}
+
+// PatchEntity updates fields of a single entity, e.g. {"title": "..."} or {"properties": {...}}
+func (c *Client) PatchEntity(blueprintID, identifier string, patch map[string]interface{}) error {
+	token, err := c.getToken()
+	if err != nil {
+		return err
+	}
+
+	bodyBytes, _ := json.Marshal(patch)
+
 	req, _ := http.NewRequest(
 		"PATCH",
-		fmt.Sprintf("%s/v1/blueprints/%s/datasource/bulk", c.baseURL, blueprintID),
+		c.endpoint(entityPath, "blueprint", blueprintID, "identifier", identifier),
 		bytes.NewReader(bodyBytes),
 	)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("patch failed: %s", string(body))
+		return newAPIError("patch entity", resp)
 	}
 
 	return nil
 }
-
@@ -0,0 +1,568 @@
+package port_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omby8888/port-github-migrator/internal/port"
+	"github.com/omby8888/port-github-migrator/internal/port/porttest"
+)
+
+const (
+	oldInstallID = "12345"
+	newInstallID = "67890"
+	version      = "1.0.0"
+)
+
+// addRepos adds n repositories of the old installation, repo-0 to repo-<n-1>
+func addRepos(srv *porttest.Server, n int) {
+	for i := 0; i < n; i++ {
+		srv.AddEntities("githubRepository", port.Entity{
+			Identifier: fmt.Sprintf("repo-%d", i),
+			Datasource: port.OldDatasource(oldInstallID),
+		})
+	}
+}
+
+func identifiers(entities []port.Entity) []string {
+	ids := make([]string, len(entities))
+	for i, e := range entities {
+		ids[i] = e.Identifier
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestAuthenticate(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+
+	if err := srv.Client().Authenticate(); err != nil {
+		t.Fatalf("Authenticate() failed: %v", err)
+	}
+
+	err := port.NewClient(srv.URL, porttest.ClientID, "wrong-secret").Authenticate()
+	var apiErr *port.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Authenticate() with a wrong secret = %v, want a 401", err)
+	}
+}
+
+func TestTokenRefresh(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn int
+		wantAuths int
+	}{
+		{"token reused while valid", 3600, 1},
+		// Tokens expiring within 3 minutes are refreshed before each request
+		{"token about to expire is refreshed", 60, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := porttest.NewServer()
+			defer srv.Close()
+			srv.TokenExpiresIn = tt.expiresIn
+			srv.SetIntegrationVersion(newInstallID, version)
+
+			client := srv.Client()
+			for i := 0; i < 3; i++ {
+				if _, err := client.GetIntegrationVersion(newInstallID); err != nil {
+					t.Fatalf("GetIntegrationVersion() failed: %v", err)
+				}
+			}
+
+			if got := srv.Requests("POST /v1/auth/access_token"); got != tt.wantAuths {
+				t.Errorf("got %d authentications, want %d", got, tt.wantAuths)
+			}
+		})
+	}
+}
+
+func TestGetBlueprintsByInstallations(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	srv.AddDataSource(oldInstallID, "githubRepository", "githubIssue")
+	srv.AddDataSource(newInstallID, "githubRepository", "githubTeam")
+	// A second datasource of the old installation adds to its blueprints
+	srv.AddDataSource(oldInstallID, "githubRepository", "githubPullRequest")
+	srv.AddDataSource("99999", "jiraIssue")
+
+	client := srv.Client()
+	blueprints, err := client.GetBlueprintsByInstallations(oldInstallID, newInstallID, "00000")
+	if err != nil {
+		t.Fatalf("GetBlueprintsByInstallations() failed: %v", err)
+	}
+	want := map[string][]string{
+		oldInstallID: {"githubIssue", "githubPullRequest", "githubRepository"},
+		newInstallID: {"githubRepository", "githubTeam"},
+		"00000":      {},
+	}
+	if fmt.Sprint(blueprints) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", blueprints, want)
+	}
+	if got := srv.Requests("GET /v1/data-sources"); got != 1 {
+		t.Errorf("got %d datasources requests, want 1", got)
+	}
+
+	for id, want := range map[string][]string{oldInstallID: want[oldInstallID], newInstallID: want[newInstallID]} {
+		got, err := client.GetBlueprintsByDataSource(id)
+		if err != nil {
+			t.Fatalf("GetBlueprintsByDataSource(%s) failed: %v", id, err)
+		}
+		sort.Strings(got)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("GetBlueprintsByDataSource(%s) = %v, want %v", id, got, want)
+		}
+	}
+	if _, err := client.GetBlueprintsByDataSource("00000"); err == nil {
+		t.Error("GetBlueprintsByDataSource() of an installation without datasources succeeded")
+	}
+}
+
+func TestSearchPagination(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	srv.PageSize = 2
+	addRepos(srv, 5)
+	// Another installation's entity is filtered out by the datasource rules
+	srv.AddEntities("githubRepository", port.Entity{Identifier: "other", Datasource: port.OldDatasource("99999")})
+
+	var pages []int
+	client := srv.Client(port.WithSearchProgress(func(blueprint string, fetched, page int) {
+		pages = append(pages, fetched)
+	}))
+	entities, err := client.SearchOldEntitiesByBlueprint("githubRepository", oldInstallID)
+	if err != nil {
+		t.Fatalf("SearchOldEntitiesByBlueprint() failed: %v", err)
+	}
+
+	want := []string{"repo-0", "repo-1", "repo-2", "repo-3", "repo-4"}
+	if got := identifiers(entities); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if fmt.Sprint(pages) != "[2 4 5]" {
+		t.Errorf("search progress reported %v, want [2 4 5]", pages)
+	}
+	if got := srv.Requests("POST /v1/blueprints/githubRepository/entities/search"); got != 3 {
+		t.Errorf("got %d search requests, want 3", got)
+	}
+}
+
+func TestSearchCollapsesOverlappingPages(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	srv.PageSize = 10
+	srv.PageOverlap = 3
+	addRepos(srv, 25)
+
+	var log bytes.Buffer
+	entities, err := srv.Client(port.WithLog(&log)).SearchOldEntitiesByBlueprint("githubRepository", oldInstallID)
+	if err != nil {
+		t.Fatalf("SearchOldEntitiesByBlueprint() failed: %v", err)
+	}
+
+	// Pages 0-9, 7-16, 14-23 and 21-24 repeat 3 entities each after the first
+	if len(entities) != 25 {
+		t.Errorf("got %d entities, want 25", len(entities))
+	}
+	ids := identifiers(entities)
+	for i := 1; i < len(ids); i++ {
+		if ids[i] == ids[i-1] {
+			t.Errorf("%s returned twice", ids[i])
+		}
+	}
+	if got := srv.Requests("POST /v1/blueprints/githubRepository/entities/search"); got != 4 {
+		t.Errorf("got %d search requests, want 4", got)
+	}
+	if !strings.Contains(log.String(), "collapsed 9 duplicate entities") {
+		t.Errorf("log doesn't report the 9 collapsed duplicates:\n%s", log.String())
+	}
+}
+
+func TestSampleOldEntitiesStopsEarly(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	srv.PageSize = 2
+	addRepos(srv, 10)
+
+	entities, err := srv.Client().SampleOldEntities("githubRepository", oldInstallID, 3)
+	if err != nil {
+		t.Fatalf("SampleOldEntities() failed: %v", err)
+	}
+	if len(entities) != 3 {
+		t.Errorf("got %d entities, want 3", len(entities))
+	}
+	if got := srv.Requests("POST /v1/blueprints/githubRepository/entities/search"); got != 2 {
+		t.Errorf("got %d search requests, want 2", got)
+	}
+}
+
+func TestGetEntity(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	addRepos(srv, 2)
+
+	client := srv.Client()
+	entity, err := client.GetEntity("githubRepository", "repo-1")
+	if err != nil {
+		t.Fatalf("GetEntity() failed: %v", err)
+	}
+	if entity.Identifier != "repo-1" || entity.Datasource != port.OldDatasource(oldInstallID) {
+		t.Errorf("got %+v, want repo-1 on the old datasource", entity)
+	}
+
+	for _, tt := range []struct{ blueprint, identifier string }{
+		{"githubRepository", "missing"},
+		{"missing", "repo-1"},
+	} {
+		if _, err := client.GetEntity(tt.blueprint, tt.identifier); !port.IsNotFound(err) {
+			t.Errorf("GetEntity(%s, %s) = %v, want a 404", tt.blueprint, tt.identifier, err)
+		}
+	}
+}
+
+func TestPatchEntitiesDatasourceBulk(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	addRepos(srv, 2)
+
+	client := srv.Client()
+	datasource := client.NewDatasource(version, newInstallID)
+	result, err := client.PatchEntitiesDatasourceBulk("githubRepository", []string{"repo-0", "repo-1", "missing"}, datasource)
+	if err != nil {
+		t.Fatalf("PatchEntitiesDatasourceBulk() failed: %v", err)
+	}
+
+	sort.Strings(result.Confirmed)
+	if fmt.Sprint(result.Confirmed) != "[repo-0 repo-1]" {
+		t.Errorf("confirmed %v, want [repo-0 repo-1]", result.Confirmed)
+	}
+	if _, failed := result.Failed["missing"]; !failed || len(result.Failed) != 1 {
+		t.Errorf("failed %v, want only missing", result.Failed)
+	}
+	if result.Requests != 1 {
+		t.Errorf("sent %d requests, want 1", result.Requests)
+	}
+
+	migrated, err := client.SearchNewEntitiesByBlueprint("githubRepository", newInstallID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := identifiers(migrated); fmt.Sprint(got) != "[repo-0 repo-1]" {
+		t.Errorf("entities on the new datasource %v, want [repo-0 repo-1]", got)
+	}
+}
+
+func TestPatchEntitiesDatasourceBulkUnreportedIdentifiers(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	addRepos(srv, 3)
+	srv.PatchUnreported = map[string]bool{"repo-1": true}
+
+	client := srv.Client()
+	datasource := client.NewDatasource(version, newInstallID)
+	result, err := client.PatchEntitiesDatasourceBulk("githubRepository", []string{"repo-0", "repo-1", "repo-2"}, datasource)
+	if err != nil {
+		t.Fatalf("PatchEntitiesDatasourceBulk() failed: %v", err)
+	}
+
+	sort.Strings(result.Confirmed)
+	if fmt.Sprint(result.Confirmed) != "[repo-0 repo-2]" {
+		t.Errorf("confirmed %v, want [repo-0 repo-2]", result.Confirmed)
+	}
+	if message := result.Failed["repo-1"]; message != "not confirmed by Port" || len(result.Failed) != 1 {
+		t.Errorf("failed %v, want repo-1 not confirmed by Port", result.Failed)
+	}
+}
+
+func TestPatchEntitiesDatasourceBulkSplitsLargeBodies(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	addRepos(srv, 8)
+
+	var ids []string
+	for _, e := range srv.Entities("githubRepository") {
+		ids = append(ids, e.Identifier)
+	}
+
+	// Room for about two identifiers per body
+	client := srv.Client(port.WithMaxPatchBodySize(120))
+	result, err := client.PatchEntitiesDatasourceBulk("githubRepository", ids, client.NewDatasource(version, newInstallID))
+	if err != nil {
+		t.Fatalf("PatchEntitiesDatasourceBulk() failed: %v", err)
+	}
+
+	if len(result.Confirmed) != len(ids) || len(result.Failed) != 0 {
+		t.Errorf("confirmed %d and failed %d, want %d confirmed", len(result.Confirmed), len(result.Failed), len(ids))
+	}
+	patches := srv.Patches()
+	if result.Requests != len(patches) || len(patches) < 2 {
+		t.Errorf("result counts %d requests, server received %d, want a split into several", result.Requests, len(patches))
+	}
+	patched := 0
+	for _, p := range patches {
+		patched += len(p.Identifiers)
+	}
+	if patched != len(ids) {
+		t.Errorf("patched %d identifiers across the split batches, want %d", patched, len(ids))
+	}
+}
+
+func TestPatchEntitiesDatasourceBulkSplitKeepsPartialResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		failing string // identifier whose request fails
+	}{
+		{"nested half of the first half fails", "repo-3"},
+		{"nested half of the second half fails", "repo-6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := porttest.NewServer()
+			defer srv.Close()
+			addRepos(srv, 8)
+			srv.PatchStatus = map[string]int{tt.failing: http.StatusInternalServerError}
+
+			ids := identifiers(srv.Entities("githubRepository"))
+			// Room for about two identifiers per body, the 8 are split two levels deep
+			client := srv.Client(port.WithMaxPatchBodySize(120))
+			result, err := client.PatchEntitiesDatasourceBulk("githubRepository", ids, client.NewDatasource(version, newInstallID))
+			if err == nil {
+				t.Fatal("PatchEntitiesDatasourceBulk() succeeded, want the failed half's error")
+			}
+			if result == nil {
+				t.Fatal("PatchEntitiesDatasourceBulk() dropped the result of the halves patched before the failure")
+			}
+
+			// Every entity Port patched is confirmed, and only those
+			var patched []string
+			for _, e := range srv.Entities("githubRepository") {
+				if e.Datasource != port.OldDatasource(oldInstallID) {
+					patched = append(patched, e.Identifier)
+				}
+			}
+			sort.Strings(patched)
+			confirmed := append([]string(nil), result.Confirmed...)
+			sort.Strings(confirmed)
+			if len(patched) == 0 || fmt.Sprint(confirmed) != fmt.Sprint(patched) {
+				t.Errorf("confirmed %v, Port patched %v", confirmed, patched)
+			}
+		})
+	}
+}
+
+func TestCheckWriteAccess(t *testing.T) {
+	tests := []struct {
+		name             string
+		status           int
+		contentType      string
+		wantErr          bool
+		wantInconclusive bool
+	}{
+		{"patched", http.StatusOK, "application/json", false, false},
+		{"payload rejected by Port", http.StatusUnprocessableEntity, "application/json", false, false},
+		{"read-only credentials", http.StatusForbidden, "application/json", true, false},
+		{"server error", http.StatusInternalServerError, "application/json", true, true},
+		{"proxy error page", http.StatusBadRequest, "text/html", true, true},
+		{"not found", http.StatusNotFound, "application/json", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := porttest.NewServer()
+			defer srv.Close()
+
+			// Answer the probe with the status, the rest goes to the fake Port
+			target, _ := url.Parse(srv.URL)
+			proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPatch {
+					w.Header().Set("Content-Type", tt.contentType)
+					w.WriteHeader(tt.status)
+					fmt.Fprint(w, `{"ok":false}`)
+					return
+				}
+				httputil.NewSingleHostReverseProxy(target).ServeHTTP(w, r)
+			}))
+			defer proxy.Close()
+
+			client := port.NewClient(proxy.URL, porttest.ClientID, porttest.ClientSecret)
+			err := client.CheckWriteAccess("githubRepository", port.OldDatasource(oldInstallID))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckWriteAccess() = %v, want error %v", err, tt.wantErr)
+			}
+			if errors.Is(err, port.ErrInconclusive) != tt.wantInconclusive {
+				t.Errorf("CheckWriteAccess() = %v, want inconclusive %v", err, tt.wantInconclusive)
+			}
+		})
+	}
+}
+
+func TestCountEntities(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	addRepos(srv, 3)
+
+	client := srv.Client()
+	count, err := client.CountEntitiesByDatasource("githubRepository", port.OldDatasource(oldInstallID))
+	if err != nil {
+		t.Fatalf("CountEntitiesByDatasource() failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("got %d, want 3", count)
+	}
+	if got := srv.Requests("POST /v1/blueprints/githubRepository/entities/search"); got != 0 {
+		t.Errorf("counted with %d searches, want the aggregation only", got)
+	}
+}
+
+func TestCountEntitiesFallsBackToSearch(t *testing.T) {
+	for _, status := range []int{http.StatusNotImplemented, http.StatusMethodNotAllowed} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			srv := porttest.NewServer()
+			defer srv.Close()
+			srv.AggregateStatus = status
+			addRepos(srv, 3)
+
+			client := srv.Client()
+			for i := 0; i < 2; i++ {
+				count, err := client.CountEntitiesByDatasource("githubRepository", port.OldDatasource(oldInstallID))
+				if err != nil {
+					t.Fatalf("CountEntitiesByDatasource() failed: %v", err)
+				}
+				if count != 3 {
+					t.Errorf("got %d, want 3", count)
+				}
+			}
+
+			// Once unsupported, the client stops asking for aggregations
+			if got := srv.Requests("POST /v1/blueprints/githubRepository/entities/aggregate"); got != 1 {
+				t.Errorf("got %d aggregations, want 1", got)
+			}
+			if got := srv.Requests("POST /v1/blueprints/githubRepository/entities/search"); got != 2 {
+				t.Errorf("got %d searches, want 2", got)
+			}
+			// The rejected probe doesn't count toward --max-failures
+			if got := client.FailedRequests(); got != 0 {
+				t.Errorf("FailedRequests() = %d, want 0", got)
+			}
+			if got := client.ErrorClasses(); len(got) != 0 {
+				t.Errorf("ErrorClasses() = %v, want none", got)
+			}
+		})
+	}
+}
+
+func TestCountEntitiesMissingBlueprint(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	addRepos(srv, 3)
+
+	client := srv.Client()
+	_, err := client.CountEntitiesByDatasource("missing", port.OldDatasource(oldInstallID))
+	if !port.IsNotFound(err) {
+		t.Fatalf("CountEntitiesByDatasource() = %v, want a 404", err)
+	}
+
+	// The blueprint's 404 doesn't switch the client to counting by search
+	count, err := client.CountEntitiesByDatasource("githubRepository", port.OldDatasource(oldInstallID))
+	if err != nil {
+		t.Fatalf("CountEntitiesByDatasource() failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("got %d, want 3", count)
+	}
+	if got := srv.Requests("POST /v1/blueprints/githubRepository/entities/aggregate"); got != 1 {
+		t.Errorf("got %d aggregations, want 1", got)
+	}
+	if got := srv.Requests("POST /v1/blueprints/missing/entities/search") + srv.Requests("POST /v1/blueprints/githubRepository/entities/search"); got != 0 {
+		t.Errorf("got %d searches, want none", got)
+	}
+}
+
+func TestCustomAPIPaths(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	srv.APIVersion = "v2"
+	srv.SearchPath = "/search/{blueprint}"
+	srv.SetIntegrationVersion(newInstallID, version)
+	addRepos(srv, 2)
+
+	client := srv.Client()
+	if _, err := client.GetIntegrationVersion(newInstallID); err != nil {
+		t.Fatalf("GetIntegrationVersion() failed: %v", err)
+	}
+	entities, err := client.SearchOldEntitiesByBlueprint("githubRepository", oldInstallID)
+	if err != nil {
+		t.Fatalf("SearchOldEntitiesByBlueprint() failed: %v", err)
+	}
+	if len(entities) != 2 {
+		t.Errorf("got %d entities, want 2", len(entities))
+	}
+	if got := srv.Requests("POST /v2/search/githubRepository"); got != 1 {
+		t.Errorf("got %d requests on the custom search path, want 1", got)
+	}
+
+	// A client on the default paths doesn't reach the server's
+	if _, err := port.NewClient(srv.URL, porttest.ClientID, porttest.ClientSecret).GetIntegrationVersion(newInstallID); err == nil {
+		t.Error("expected a client on /v1 to fail against a /v2 server")
+	}
+}
+
+func TestWithContextCancelsRequestInFlight(t *testing.T) {
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hung.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	client := port.NewClient(hung.URL, porttest.ClientID, porttest.ClientSecret, port.WithContext(ctx))
+
+	start := time.Now()
+	err := client.Authenticate()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Authenticate() = %v, want the deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the request took %s, it wasn't cancelled at the deadline", elapsed)
+	}
+	// Cancelled requests don't count toward --max-failures
+	if got := client.FailedRequests(); got != 0 {
+		t.Errorf("FailedRequests() = %d, want 0", got)
+	}
+}
+
+func TestWithContextBoundsRateLimiterWait(t *testing.T) {
+	srv := porttest.NewServer()
+	defer srv.Close()
+	srv.SetIntegrationVersion(newInstallID, version)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	// The authentication takes the only token, the next request would wait 100s
+	client := srv.Client(port.WithMaxRPS(0.01), port.WithContext(ctx))
+
+	start := time.Now()
+	_, err := client.GetIntegrationVersion(newInstallID)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetIntegrationVersion() = %v, want the deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited %s for the rate limiter, past the deadline", elapsed)
+	}
+	if got := srv.Requests("GET /v1/integration/" + newInstallID); got != 0 {
+		t.Errorf("got %d requests past the deadline, want 0", got)
+	}
+}
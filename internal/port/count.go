@@ -0,0 +1,121 @@
+package port
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// AggregateResponse represents the result of an entity count aggregation
+type AggregateResponse struct {
+	Result *int `json:"result"`
+}
+
+// CountEntitiesByDatasource counts a blueprint's entities whose datasource contains datasourceValue.
+// It asks Port for a count aggregation and falls back to a full search when the aggregate
+// endpoint answers 501 or 405, after which the client keeps using the search.
+func (c *Client) CountEntitiesByDatasource(blueprintID, datasourceValue string) (int, error) {
+	query := map[string]interface{}{
+		"combinator": "and",
+		"rules": []map[string]interface{}{
+			{
+				"property": "$datasource",
+				"operator": "contains",
+				"value":    datasourceValue,
+			},
+		},
+	}
+
+	return c.countEntities(blueprintID, query)
+}
+
+// CountNewEntities counts a blueprint's entities on the new GitHub Ocean installation's datasource
+func (c *Client) CountNewEntities(blueprintID, newInstallationID string) (int, error) {
+	query := map[string]interface{}{
+		"combinator": "and",
+		"rules":      c.newDatasourceRules(newInstallationID),
+	}
+
+	return c.countEntities(blueprintID, query)
+}
+
+// countEntities counts the blueprint's entities matching query
+func (c *Client) countEntities(blueprintID string, query map[string]interface{}) (int, error) {
+	if !c.aggregateUnsupported.Load() {
+		count, err := c.aggregateCount(blueprintID, query)
+		if err == nil {
+			return count, nil
+		}
+		if !isAggregateUnsupported(err) {
+			return 0, err
+		}
+		c.aggregateUnsupported.Store(true)
+	}
+
+	entities, err := c.searchEntitiesByBlueprint(blueprintID, query)
+	if err != nil {
+		return 0, err
+	}
+	return len(entities), nil
+}
+
+// aggregateCount runs a count aggregation over the blueprint's entities matching query
+func (c *Client) aggregateCount(blueprintID string, query map[string]interface{}) (int, error) {
+	token, err := c.getToken()
+	if err != nil {
+		return 0, err
+	}
+
+	reqBody := map[string]interface{}{
+		"func":  "count",
+		"query": query,
+	}
+	bodyBytes, _ := json.Marshal(reqBody)
+
+	req, _ := http.NewRequest(
+		"POST",
+		c.endpoint(aggregatePath, "blueprint", blueprintID),
+		bytes.NewReader(bodyBytes),
+	)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.send(req)
+	// A Port without aggregations is expected, rejecting the probe isn't a failed request
+	if err != nil || !aggregateUnsupportedStatus(resp.StatusCode) {
+		c.recordOutcome(resp, err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, newAPIError("aggregate", resp)
+	}
+
+	var aggResp AggregateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&aggResp); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if aggResp.Result == nil {
+		// A 200 without a count means the endpoint isn't the aggregation we expect
+		return 0, &APIError{Operation: "aggregate", StatusCode: http.StatusNotImplemented, Body: "response has no count"}
+	}
+
+	return *aggResp.Result, nil
+}
+
+// isAggregateUnsupported reports whether an aggregation failed because Port doesn't support it.
+// Other errors, such as the 404 of a missing blueprint, are the blueprint's and are returned.
+func isAggregateUnsupported(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && aggregateUnsupportedStatus(apiErr.StatusCode)
+}
+
+// aggregateUnsupportedStatus reports whether the aggregate endpoint's status means Port doesn't support it
+func aggregateUnsupportedStatus(status int) bool {
+	return status == http.StatusNotImplemented || status == http.StatusMethodNotAllowed
+}
@@ -0,0 +1,82 @@
+package port
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodyLength is how much of a non-JSON error body is kept in an APIError
+const maxErrorBodyLength = 200
+
+// APIError is returned when Port responds with an unexpected status code
+type APIError struct {
+	Operation  string
+	StatusCode int
+	Body       string
+}
+
+// ErrInconclusive is wrapped by CheckWriteAccess when the probe's response shows neither that the
+// credentials may write nor that they may not, e.g. a 5xx or a proxy's error page
+var ErrInconclusive = errors.New("inconclusive")
+
+// writeOperations are the operations that modify entities, a 403 on them usually means read-only credentials
+var writeOperations = map[string]bool{"patch": true, "patch entity": true}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == http.StatusForbidden && writeOperations[e.Operation] {
+		return fmt.Sprintf("%s failed: insufficient permissions, your credentials may be read-only: %s", e.Operation, e.Body)
+	}
+	return fmt.Sprintf("%s failed: %s", e.Operation, e.Body)
+}
+
+// newAPIError reads the body of an unexpected response into an APIError. JSON bodies are kept
+// as they are, others such as a proxy's HTML error page are summarized so errors stay legible.
+func newAPIError(operation string, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	return &APIError{Operation: operation, StatusCode: resp.StatusCode, Body: summarizeErrorBody(resp, body)}
+}
+
+// summarizeErrorBody keeps JSON bodies and truncates the rest
+func summarizeErrorBody(resp *http.Response, body []byte) string {
+	text := strings.TrimSpace(string(body))
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	switch {
+	case isJSONResponse(resp):
+		return text
+	case text == "":
+		return fmt.Sprintf("empty response, status %d", resp.StatusCode)
+	case mediaType == "text/html" || strings.HasPrefix(text, "<"):
+		return fmt.Sprintf("received HTML error page, status %d", resp.StatusCode)
+	case strings.HasPrefix(text, "{") || strings.HasPrefix(text, "["):
+		// JSON served without a JSON content type
+		return text
+	}
+
+	if len(text) > maxErrorBodyLength {
+		text = text[:maxErrorBodyLength] + "…"
+	}
+	return fmt.Sprintf("status %d: %s", resp.StatusCode, text)
+}
+
+// isJSONResponse reports whether the response declares a JSON body, as Port's own errors do
+func isJSONResponse(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// IsForbidden reports whether err is a Port 403 response
+func IsForbidden(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden
+}
+
+// IsNotFound reports whether err is a Port 404 response
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,60 @@
+package port
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultAPIVersion is the version prefix of the Port API paths
+const DefaultAPIVersion = "v1"
+
+// DefaultSearchPath is the entity search path, after the API version
+const DefaultSearchPath = "/blueprints/{blueprint}/entities/search"
+
+// Paths of the Port API endpoints after the API version, {name} placeholders are filled in by endpoint
+const (
+	accessTokenPath    = "/auth/access_token"
+	integrationPath    = "/integration/{installationId}"
+	dataSourcesPath    = "/data-sources"
+	aggregatePath      = "/blueprints/{blueprint}/entities/aggregate"
+	datasourceBulkPath = "/blueprints/{blueprint}/datasource/bulk"
+	entityPath         = "/blueprints/{blueprint}/entities/{identifier}"
+)
+
+// WithAPIVersion overrides the API version prefix of every path, e.g. for a self-hosted Port
+// on another version, an empty value keeps v1
+func WithAPIVersion(version string) Option {
+	return func(c *Client) {
+		if version != "" {
+			c.apiVersion = strings.Trim(version, "/")
+		}
+	}
+}
+
+// WithSearchPath overrides the entity search path after the API version, the template must
+// contain the {blueprint} placeholder, an empty value keeps the default
+func WithSearchPath(template string) Option {
+	return func(c *Client) {
+		if template != "" {
+			c.searchPath = "/" + strings.TrimPrefix(template, "/")
+		}
+	}
+}
+
+// ValidateSearchPath checks that a search path template has the {blueprint} placeholder
+func ValidateSearchPath(template string) error {
+	if !strings.Contains(template, "{blueprint}") {
+		return fmt.Errorf("search path %q has no {blueprint} placeholder", template)
+	}
+	return nil
+}
+
+// endpoint builds the URL of an API path, params are placeholder name and value pairs,
+// e.g. endpoint(entityPath, "blueprint", bp, "identifier", id). Values are path-escaped.
+func (c *Client) endpoint(path string, params ...string) string {
+	for i := 0; i+1 < len(params); i += 2 {
+		path = strings.ReplaceAll(path, "{"+params[i]+"}", url.PathEscape(params[i+1]))
+	}
+	return c.baseURL + "/" + c.apiVersion + path
+}
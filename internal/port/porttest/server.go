@@ -0,0 +1,563 @@
+// Package porttest provides an in-memory fake of the Port API for testing code built on port.Client.
+//
+// The fake covers the endpoints the client uses: authentication, integrations,
+// data-sources, paginated entity search with $datasource rules, count aggregations,
+// bulk datasource patches, and fetching and patching single entities.
+//
+//	srv := porttest.NewServer()
+//	defer srv.Close()
+//
+//	srv.AddDataSource("12345", "githubRepository")
+//	srv.AddEntities("githubRepository", port.Entity{Identifier: "repo", Datasource: port.OldDatasource("12345")})
+//
+//	client := srv.Client()
+//	entities, err := client.SearchOldEntitiesByBlueprint("githubRepository", "12345")
+package porttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/omby8888/port-github-migrator/internal/port"
+)
+
+// Token is the access token returned by the fake authentication endpoint
+const Token = "porttest-token"
+
+// PatchCall records a bulk datasource patch received by the server
+type PatchCall struct {
+	Blueprint   string
+	Identifiers []string
+	Datasource  string
+}
+
+// Server is a fake Port API backed by in-memory entities
+type Server struct {
+	*httptest.Server
+
+	// PageSize caps the entities returned per search page, 0 uses the requested limit
+	PageSize int
+
+	// PageOverlap repeats that many entities of each page at the start of the next one,
+	// like an overlapping pagination cursor
+	PageOverlap int
+
+	// APIVersion and SearchPath are the paths served, as set on the client with port.WithAPIVersion
+	// and port.WithSearchPath, Client matches them. Empty values serve the client defaults.
+	APIVersion string
+	SearchPath string
+
+	// TokenExpiresIn is the lifetime in seconds of the issued access tokens, 0 means an hour
+	TokenExpiresIn int
+
+	// AggregateStatus, when set, fails count aggregations with that status, e.g. 501 for a Port without them
+	AggregateStatus int
+
+	// PatchStatus fails every bulk patch that includes one of its identifiers with the mapped status
+	PatchStatus map[string]int
+
+	// PatchUnreported patches its identifiers but leaves them out of the bulk patch response
+	PatchUnreported map[string]bool
+
+	mu           sync.Mutex
+	entities     map[string][]port.Entity // blueprint -> entities
+	dataSources  []port.DataSource
+	integrations map[string]string // installation ID -> version
+	patches      []PatchCall
+	requests     map[string]int // "METHOD path" -> count
+}
+
+// NewServer starts a fake Port API, call Close when done
+func NewServer() *Server {
+	s := &Server{
+		entities:     make(map[string][]port.Entity),
+		integrations: make(map[string]string),
+		requests:     make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Credentials accepted by the fake authentication endpoint
+const (
+	ClientID     = "client-id"
+	ClientSecret = "client-secret"
+)
+
+// Client returns a Port client pointed at the fake server, on its API version and search path
+func (s *Server) Client(opts ...port.Option) *port.Client {
+	opts = append([]port.Option{port.WithAPIVersion(s.APIVersion), port.WithSearchPath(s.SearchPath)}, opts...)
+	return port.NewClient(s.URL, ClientID, ClientSecret, opts...)
+}
+
+// AddEntities adds entities to a blueprint
+func (s *Server) AddEntities(blueprint string, entities ...port.Entity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range entities {
+		e.Blueprint = blueprint
+		s.entities[blueprint] = append(s.entities[blueprint], e)
+	}
+}
+
+// Entities returns a copy of a blueprint's current entities
+func (s *Server) Entities(blueprint string) []port.Entity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]port.Entity(nil), s.entities[blueprint]...)
+}
+
+// AddDataSource lists blueprints under an installation in the data-sources response
+func (s *Server) AddDataSource(installationID string, blueprints ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ds port.DataSource
+	ds.Context.InstallationID = installationID
+	for _, bp := range blueprints {
+		ds.Blueprints = append(ds.Blueprints, struct {
+			Identifier string `json:"identifier"`
+		}{Identifier: bp})
+	}
+	s.dataSources = append(s.dataSources, ds)
+}
+
+// SetIntegrationVersion sets the version returned for an installation
+func (s *Server) SetIntegrationVersion(installationID, version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.integrations[installationID] = version
+}
+
+// Patches returns the bulk patches received so far
+func (s *Server) Patches() []PatchCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]PatchCall(nil), s.patches...)
+}
+
+// Requests returns how many requests were received for a method and path, e.g. "POST /v1/auth/access_token"
+func (s *Server) Requests(methodAndPath string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[methodAndPath]
+}
+
+// route is an endpoint of the fake, its path template has {name} placeholders filling whole segments
+type route struct {
+	method string
+	path   string
+	handle func(w http.ResponseWriter, r *http.Request, params map[string]string)
+}
+
+// routes returns the endpoints served after the API version
+func (s *Server) routes() []route {
+	searchPath := s.SearchPath
+	if searchPath == "" {
+		searchPath = port.DefaultSearchPath
+	}
+
+	return []route{
+		{http.MethodGet, "/data-sources", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			s.handleDataSources(w)
+		}},
+		{http.MethodGet, "/integration/{installationId}", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			s.handleIntegration(w, params["installationId"])
+		}},
+		{http.MethodPost, searchPath, func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			s.handleSearch(w, r, params["blueprint"])
+		}},
+		{http.MethodPost, "/blueprints/{blueprint}/entities/aggregate", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			s.handleAggregate(w, r, params["blueprint"])
+		}},
+		{http.MethodPatch, "/blueprints/{blueprint}/datasource/bulk", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			s.handlePatch(w, r, params["blueprint"])
+		}},
+		{http.MethodGet, "/blueprints/{blueprint}/entities/{identifier}", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			s.handleGetEntity(w, params["blueprint"], params["identifier"])
+		}},
+		{http.MethodPatch, "/blueprints/{blueprint}/entities/{identifier}", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			s.handlePatchEntity(w, r, params["blueprint"], params["identifier"])
+		}},
+	}
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.Method+" "+r.URL.Path]++
+	s.mu.Unlock()
+
+	apiVersion := s.APIVersion
+	if apiVersion == "" {
+		apiVersion = port.DefaultAPIVersion
+	}
+	prefix := "/" + strings.Trim(apiVersion, "/")
+	path := r.URL.EscapedPath()
+	if !strings.HasPrefix(path, prefix+"/") {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+		return
+	}
+	path = strings.TrimPrefix(path, prefix)
+
+	if path == "/auth/access_token" && r.Method == http.MethodPost {
+		s.handleAuth(w, r)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+Token {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	for _, rt := range s.routes() {
+		if rt.method != r.Method {
+			continue
+		}
+		if params, ok := matchPath(rt.path, path); ok {
+			rt.handle(w, r, params)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+}
+
+// matchPath matches an escaped request path against a route template, returning the unescaped placeholder values
+func matchPath(template, path string) (map[string]string, bool) {
+	want := strings.Split(strings.Trim(template, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range want {
+		value, err := url.PathUnescape(got[i])
+		if err != nil {
+			return nil, false
+		}
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params[strings.Trim(segment, "{}")] = value
+		} else if segment != value {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
+	var creds struct {
+		ClientID     string `json:"clientId"`
+		ClientSecret string `json:"clientSecret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if creds.ClientID != ClientID || creds.ClientSecret != ClientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	expiresIn := s.TokenExpiresIn
+	if expiresIn == 0 {
+		expiresIn = 3600
+	}
+	writeJSON(w, http.StatusOK, port.AuthResponse{AccessToken: Token, ExpiresIn: expiresIn})
+}
+
+func (s *Server) handleDataSources(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, port.DataSourceResponse{DataSources: s.dataSources})
+}
+
+func (s *Server) handleIntegration(w http.ResponseWriter, installationID string) {
+	s.mu.Lock()
+	version, ok := s.integrations[installationID]
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "integration not found")
+		return
+	}
+
+	var resp port.IntegrationResponse
+	resp.Integration.Version = version
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// query is the subset of a search query the fake understands, its rules are combined with "and"
+type query struct {
+	Rules []struct {
+		Property string      `json:"property"`
+		Operator string      `json:"operator"`
+		Value    interface{} `json:"value"`
+	} `json:"rules"`
+}
+
+// searchRequest is the subset of the search body the fake understands
+type searchRequest struct {
+	Limit int    `json:"limit"`
+	From  string `json:"from"`
+	Query *query `json:"query"`
+}
+
+// match returns the blueprint's entities matching q, false when the blueprint doesn't exist
+func (s *Server) match(blueprint string, q *query) ([]port.Entity, bool) {
+	s.mu.Lock()
+	all, exists := s.entities[blueprint]
+	s.mu.Unlock()
+	if !exists {
+		return nil, false
+	}
+
+	var matched []port.Entity
+	for _, e := range all {
+		ok := true
+		if q != nil {
+			for _, rule := range q.Rules {
+				if !matchRule(e, rule.Property, rule.Operator, rule.Value) {
+					ok = false
+					break
+				}
+			}
+		}
+		if ok {
+			matched = append(matched, e)
+		}
+	}
+	return matched, true
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request, blueprint string) {
+	var req searchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	matched, exists := s.match(blueprint, req.Query)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("blueprint %s not found", blueprint))
+		return
+	}
+
+	// The cursor is the offset of the next page
+	start, _ := strconv.Atoi(req.From)
+	limit := req.Limit
+	if s.PageSize > 0 && (limit == 0 || s.PageSize < limit) {
+		limit = s.PageSize
+	}
+	if limit <= 0 {
+		limit = len(matched)
+	}
+	end := start + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	if start > end {
+		start = end
+	}
+
+	resp := port.SearchResponse{Entities: matched[start:end]}
+	if end < len(matched) {
+		next := end - s.PageOverlap
+		if next <= start {
+			next = start + 1
+		}
+		resp.Next = strconv.Itoa(next)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleAggregate(w http.ResponseWriter, r *http.Request, blueprint string) {
+	if s.AggregateStatus != 0 {
+		writeError(w, s.AggregateStatus, "aggregation not supported")
+		return
+	}
+
+	var req struct {
+		Func  string `json:"func"`
+		Query *query `json:"query"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Func != "count" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported aggregation %q", req.Func))
+		return
+	}
+
+	matched, exists := s.match(blueprint, req.Query)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("blueprint %s not found", blueprint))
+		return
+	}
+
+	count := len(matched)
+	writeJSON(w, http.StatusOK, port.AggregateResponse{Result: &count})
+}
+
+// matchRule evaluates a single search rule against an entity
+func matchRule(e port.Entity, property, operator string, value interface{}) bool {
+	var actual string
+	switch property {
+	case "$datasource":
+		actual = e.Datasource
+	case "$identifier":
+		actual = e.Identifier
+	case "$title":
+		actual = e.Title
+	default:
+		actual = fmt.Sprint(e.Properties[property])
+	}
+
+	switch operator {
+	case "contains":
+		return strings.Contains(actual, fmt.Sprint(value))
+	case "=":
+		return actual == fmt.Sprint(value)
+	case "in":
+		values, _ := value.([]interface{})
+		for _, v := range values {
+			if actual == fmt.Sprint(v) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func (s *Server) handlePatch(w http.ResponseWriter, r *http.Request, blueprint string) {
+	var req port.BulkPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.patches = append(s.patches, PatchCall{Blueprint: blueprint, Identifiers: req.EntitiesIdentifiers, Datasource: req.Datasource})
+
+	for _, id := range req.EntitiesIdentifiers {
+		if status := s.PatchStatus[id]; status != 0 {
+			writeError(w, status, fmt.Sprintf("patching %s failed", id))
+			return
+		}
+	}
+
+	wanted := make(map[string]bool, len(req.EntitiesIdentifiers))
+	for _, id := range req.EntitiesIdentifiers {
+		wanted[id] = true
+	}
+
+	var resp port.BulkPatchResponse
+	entities := s.entities[blueprint]
+	for i := range entities {
+		if !wanted[entities[i].Identifier] {
+			continue
+		}
+		entities[i].Datasource = req.Datasource
+		delete(wanted, entities[i].Identifier)
+		if s.PatchUnreported[entities[i].Identifier] {
+			continue
+		}
+		resp.Entities = append(resp.Entities, struct {
+			Identifier string `json:"identifier"`
+		}{Identifier: entities[i].Identifier})
+	}
+	for _, id := range req.EntitiesIdentifiers {
+		if wanted[id] {
+			resp.Errors = append(resp.Errors, struct {
+				Identifier string `json:"identifier"`
+				Message    string `json:"message"`
+			}{Identifier: id, Message: "entity not found"})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGetEntity(w http.ResponseWriter, blueprint, identifier string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entities[blueprint] {
+		if e.Identifier == identifier {
+			writeJSON(w, http.StatusOK, port.EntityResponse{Entity: e})
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Sprintf("entity %s not found", identifier))
+}
+
+func (s *Server) handlePatchEntity(w http.ResponseWriter, r *http.Request, blueprint, identifier string) {
+	var patch struct {
+		Title      *string                `json:"title"`
+		Properties map[string]interface{} `json:"properties"`
+		Relations  map[string]interface{} `json:"relations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entities := s.entities[blueprint]
+	for i := range entities {
+		if entities[i].Identifier != identifier {
+			continue
+		}
+		if patch.Title != nil {
+			entities[i].Title = *patch.Title
+		}
+		if patch.Properties != nil && entities[i].Properties == nil {
+			entities[i].Properties = make(map[string]interface{})
+		}
+		for k, v := range patch.Properties {
+			entities[i].Properties[k] = v
+		}
+		if patch.Relations != nil {
+			relations, _ := entities[i].Relations.(map[string]interface{})
+			if relations == nil {
+				relations = make(map[string]interface{})
+			}
+			for k, v := range patch.Relations {
+				relations[k] = v
+			}
+			entities[i].Relations = relations
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "entity": entities[i]})
+		return
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Sprintf("entity %s not found", identifier))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"ok": false, "message": message})
+}
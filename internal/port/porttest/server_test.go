@@ -0,0 +1,32 @@
+package porttest
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		want     map[string]string // nil means no match
+	}{
+		{"/data-sources", "/data-sources", map[string]string{}},
+		{"/blueprints/{blueprint}/entities/search", "/blueprints/repo/entities/search", map[string]string{"blueprint": "repo"}},
+		{"/blueprints/{blueprint}/entities/search", "/blueprints/repo/entities/aggregate", nil},
+		{"/blueprints/{blueprint}/entities/{identifier}", "/blueprints/repo/entities/org%2Fname", map[string]string{"blueprint": "repo", "identifier": "org/name"}},
+		{"/blueprints/{blueprint}/entities/{identifier}", "/blueprints/repo/entities", nil},
+		{"/search/{blueprint}", "/search/repo/", map[string]string{"blueprint": "repo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := matchPath(tt.template, tt.path)
+			if ok != (tt.want != nil) {
+				t.Fatalf("matchPath(%q, %q) matched = %v, want %v", tt.template, tt.path, ok, tt.want != nil)
+			}
+			if ok && fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("matchPath(%q, %q) = %v, want %v", tt.template, tt.path, got, tt.want)
+			}
+		})
+	}
+}
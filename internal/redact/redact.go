@@ -0,0 +1,66 @@
+// Package redact masks secrets before they are printed, logged or sent anywhere. Every output
+// feature goes through it, so a new one can't leak the client secret or the access token by accident.
+package redact
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// minSecretLength is the shortest value Text masks, shorter ones are too likely to be part of
+// ordinary output
+const minSecretLength = 6
+
+// bearerToken matches the token of an Authorization header value
+var bearerToken = regexp.MustCompile(`(?i)(bearer\s+)([A-Za-z0-9\-._~+/]+=*)`)
+
+// Secret masks a secret, keeping a short prefix for identification
+func Secret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return secret[:4] + "****"
+}
+
+// Text masks every bearer token and every occurrence of the given secrets in text
+func Text(text string, secrets ...string) string {
+	text = bearerToken.ReplaceAllStringFunc(text, func(match string) string {
+		parts := bearerToken.FindStringSubmatch(match)
+		return parts[1] + Secret(parts[2])
+	})
+	for _, secret := range secrets {
+		if len(secret) < minSecretLength {
+			continue
+		}
+		text = strings.ReplaceAll(text, secret, Secret(secret))
+	}
+	return text
+}
+
+// Writer returns a writer passing everything written to w through Text. Each Write is masked on
+// its own, so a secret split across two writes isn't masked. fmt writes a whole formatted line at
+// once, which is how the commands print; the writer doesn't buffer by line, as prompts end without one.
+func Writer(w io.Writer, secrets ...string) io.Writer {
+	return &writer{w: w, secrets: secrets}
+}
+
+type writer struct {
+	w       io.Writer
+	secrets []string
+}
+
+// Unwrap returns the writer the masked output goes to, e.g. to check whether it's a terminal
+func (r *writer) Unwrap() io.Writer {
+	return r.w
+}
+
+func (r *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Text(string(p), r.secrets...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+	"github.com/omby8888/port-github-migrator/internal/redact"
+)
+
+// Report is the data of a human-readable migration report, for change tickets and audits
+type Report struct {
+	StartedAt          time.Time
+	FinishedAt         time.Time
+	Command            string // the invocation, secrets redacted
+	DryRun             bool
+	IntegrationVersion string
+	OldDatasource      string
+	NewDatasource      string
+	Stats              *models.MigrationStats
+	Error              string
+}
+
+// Status is "success", "timeout" when the migration stopped at its --deadline, or "failure" when
+// it returned an error or a blueprint failed
+func (r Report) Status() string {
+	if r.Stats != nil && r.Stats.DeadlineExceeded {
+		return "timeout"
+	}
+	if r.Error != "" || (r.Stats != nil && r.Stats.FailedBatches > 0) {
+		return "failure"
+	}
+	return "success"
+}
+
+// Blueprints returns the blueprints that had entities patched, sorted
+func (r Report) Blueprints() []string {
+	if r.Stats == nil {
+		return nil
+	}
+	blueprints := make([]string, 0, len(r.Stats.PatchedEntities))
+	for bp := range r.Stats.PatchedEntities {
+		blueprints = append(blueprints, bp)
+	}
+	sort.Strings(blueprints)
+	return blueprints
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`# GitHub Ocean Migration Report
+
+- **Status:** {{.Status}}{{if .Error}} ({{.Error}}){{end}}
+- **Mode:** {{if .DryRun}}dry run, no changes were made{{else}}migration{{end}}
+- **Started:** {{.StartedAt.Format "2006-01-02 15:04:05 MST"}}
+- **Finished:** {{.FinishedAt.Format "2006-01-02 15:04:05 MST"}}
+- **Command:** ` + "`{{.Command}}`" + `
+
+## Datasource Transition
+
+- **Integration version:** {{.IntegrationVersion}}
+- **Old datasource:** ` + "`{{.OldDatasource}}`" + `
+- **New datasource:** ` + "`{{.NewDatasource}}`" + `
+{{with .Stats}}
+## Summary
+
+- **Blueprints:** {{.TotalBlueprints}} ({{.SuccessfulBatches}} succeeded, {{.FailedBatches}} failed)
+- **Entities affected:** {{.TotalEntities}}
+{{- if .StaleBlueprints}}
+- **Skipped, no longer exist:** {{range $i, $bp := .StaleBlueprints}}{{if $i}}, {{end}}{{$bp}}{{end}}
+{{- end}}
+{{- if .UningestedBlueprints}}
+- **Nothing on the new datasource yet:** {{range $i, $bp := .UningestedBlueprints}}{{if $i}}, {{end}}{{$bp}}{{end}}
+{{- end}}
+{{end}}
+{{- if .Blueprints}}
+## Patched Entities
+
+| Blueprint | Entities |
+|---|---|
+{{- range .Blueprints}}
+| {{.}} | {{index $.Stats.PatchedEntities .}} |
+{{- end}}
+{{end}}
+{{- with .Stats}}{{if .Failures}}
+## Failures
+
+{{range .Failures}}- **{{.Blueprint}}**: {{if .Identifiers}}{{len .Identifiers}} entities{{else}}whole blueprint{{end}}, {{.Error}}
+{{end}}{{end}}{{end}}`))
+
+// Write renders the report as Markdown to path, masking the secrets wherever they show up, e.g. in an error
+func Write(path string, r Report, secrets ...string) error {
+	var rendered bytes.Buffer
+	if err := reportTemplate.Execute(&rendered, r); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	if err := os.WriteFile(path, []byte(redact.Text(rendered.String(), secrets...)), 0o644); err != nil {
+		return fmt.Errorf("failed to write report file: %w", err)
+	}
+	return nil
+}
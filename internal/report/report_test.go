@@ -0,0 +1,61 @@
+package report
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omby8888/port-github-migrator/internal/models"
+)
+
+func TestWriteMasksSecrets(t *testing.T) {
+	const secret = "client-secret-value"
+	path := filepath.Join(t.TempDir(), "report.md")
+
+	r := Report{
+		StartedAt:  time.Now(),
+		FinishedAt: time.Now(),
+		Command:    "port-github-migrator migrate --all --client-secret=clie****",
+		Error:      errors.New("authentication failed for " + secret).Error(),
+		Stats: &models.MigrationStats{
+			Failures: []models.FailedBatch{{Blueprint: "repo", Error: "Bearer " + secret}},
+		},
+	}
+	if err := Write(path, r, secret); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(written), secret) {
+		t.Errorf("report contains the secret:\n%s", written)
+	}
+	if !strings.Contains(string(written), "authentication failed for clie****") {
+		t.Errorf("report doesn't contain the masked error:\n%s", written)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Report
+		want string
+	}{
+		{"success", Report{Stats: &models.MigrationStats{SuccessfulBatches: 2}}, "success"},
+		{"failed blueprint", Report{Stats: &models.MigrationStats{FailedBatches: 1}}, "failure"},
+		{"error", Report{Error: "failed to get blueprints"}, "failure"},
+		{"deadline", Report{Error: "stopped at the --deadline", Stats: &models.MigrationStats{DeadlineExceeded: true, FailedBatches: 1}}, "timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Status(); got != tt.want {
+				t.Errorf("Status() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// Package textwidth pads the name column of the fixed layout tables by terminal columns,
+// so wide and combining characters in blueprint names don't shift the columns after it
+package textwidth
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MinNameColumn is the width of the name column of the fixed layout tables
+const MinNameColumn = 33
+
+// NameColumn returns the width of the name column, widened to fit the longest name
+// so the columns after it stay aligned
+func NameColumn(names []string) int {
+	width := MinNameColumn
+	for _, name := range names {
+		if w := Width(name); w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// Pad pads s with spaces to fill width terminal columns, unlike %-*s which counts runes
+func Pad(s string, width int) string {
+	if pad := width - Width(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// Width returns the terminal columns s takes: two for wide characters such as CJK and
+// emoji, none for combining marks, variation selectors and zero width joiners
+func Width(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case r == 0x200D || (r >= 0xFE00 && r <= 0xFE0F) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r):
+		case isWide(r):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// isWide reports whether r is an East Asian wide or fullwidth character or an emoji
+func isWide(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115F) ||
+		(r >= 0x2E80 && r <= 0xA4CF && r != 0x303F) ||
+		(r >= 0xAC00 && r <= 0xD7A3) ||
+		(r >= 0xF900 && r <= 0xFAFF) ||
+		(r >= 0xFE30 && r <= 0xFE4F) ||
+		(r >= 0xFF00 && r <= 0xFF60) ||
+		(r >= 0xFFE0 && r <= 0xFFE6) ||
+		(r >= 0x1F300 && r <= 0x1F64F) ||
+		(r >= 0x1F680 && r <= 0x1F6FF) ||
+		(r >= 0x1F900 && r <= 0x1F9FF) ||
+		(r >= 0x20000 && r <= 0x3FFFD) ||
+		r == 0x2705 || r == 0x274C || r == 0x2753 || r == 0x23F0
+}
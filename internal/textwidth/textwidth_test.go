@@ -0,0 +1,23 @@
+package textwidth
+
+import "testing"
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"githubRepository", 16},
+		{"café", 4},
+		{"cafe\u0301", 4}, // combining acute accent
+		{"服务目录", 8},
+		{"🚀deployments", 13},
+		{"⚠️", 1}, // the variation selector takes no column
+		{"✅", 2},
+	}
+	for _, tt := range tests {
+		if got := Width(tt.s); got != tt.want {
+			t.Errorf("Width(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}